@@ -1,5 +1,12 @@
-module main
+module sqlinj
+
+go 1.27.1
+
+require golang.org/x/tools v0.0.0-20190422233926-fe54fb35175b
 
 require (
-	golang.org/x/tools v0.0.0-20190422233926-fe54fb35175b
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2 // indirect
+	golang.org/x/net v0.0.0-20190311183353-d8887717615a // indirect
+	golang.org/x/sys v0.0.0-20190215142949-d0b11bdaac8a // indirect
+	golang.org/x/text v0.3.0 // indirect
 )
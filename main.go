@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"sqlinj/sqlinj"
+)
+
+var checkDir = flag.String("dir", "", "sql injection check dir")
+var summaryFile = flag.String("summaries", "", "file to load function summaries from and save them to")
+var summaryDeps = flag.Bool("deps", false, "also summarize non standard library dependencies of the checked packages")
+var sanitizerList = flag.String("sanitizers", "", "comma separated extra sanitizer functions, e.g. mypkg.QuoteIdent")
+var dialectName = flag.String("dialect", "mysql", "default sql dialect for bind placeholders: mysql, postgres, sqlite, oracle or sqlserver")
+var selectAsterisk = flag.Bool("select-asterisk", false, "also report SELECT * and alias.* in queries")
+var schemaDir = flag.String("schema", "", "directory of .sql schema or migration files to check table and column names against")
+var inventoryFile = flag.String("inventory", "", "write every db call and its reconstructed statement to this file")
+var inventoryFormat = flag.String("inventory-format", "", "inventory format, json or csv; by default csv for a .csv file and json otherwise")
+var likeEscaperList = flag.String("like-escapers", "", "comma separated extra functions that escape LIKE wildcards, e.g. mypkg.EscapeLike")
+var ruleList = flag.String("rules", "", "comma separated rule IDs to enable, or to disable with a leading -, e.g. select-asterisk,-missing-where")
+var verbose = flag.Bool("verbose", false, "print the reconstructed sql of every path that reaches a db call")
+var sinkDialectList = flag.String("sink-dialects", "", "comma separated dialects of db call types, e.g. *sqlx.DB=postgres")
+
+func main() {
+	flag.Parse()
+	sqlinj.AddSanitizers(*sanitizerList)
+	sqlinj.AddLikeEscapers(*likeEscaperList)
+	sqlinj.SetDialects(*dialectName, *sinkDialectList)
+	if *selectAsterisk {
+		sqlinj.ConfigureRules(sqlinj.RuleSelectAsterisk)
+	}
+	sqlinj.ConfigureRules(*ruleList)
+	if *schemaDir != "" {
+		if err := sqlinj.LoadSchema(*schemaDir); err != nil {
+			fmt.Println(err)
+		}
+	}
+	si := sqlinj.NewAnalyzer(*summaryFile)
+	si.Deps = *summaryDeps
+	si.Inventory = *inventoryFile != ""
+	si.Verbose = *verbose
+	si.CheckDir(*checkDir)
+	if *inventoryFile != "" {
+		if err := si.WriteInventory(*inventoryFile, *inventoryFormat); err != nil {
+			fmt.Println(err)
+		}
+	}
+	for _, err := range si.Findings() {
+		fmt.Println("error: ", err)
+	}
+}
@@ -0,0 +1,38 @@
+package sqlinj
+
+// selectAsteriskRule 检查sql语句是否存在select * from 或者 select a.* from，
+// 只检查顶层 SELECT 的选择列表，返回的列由它决定；子查询、派生表与 EXISTS (SELECT * ...) 中的 * 以及 COUNT(*) 不算
+type selectAsteriskRule struct{}
+
+func (selectAsteriskRule) ID() string {
+	return RuleSelectAsterisk
+}
+
+func (selectAsteriskRule) Check(q *Query) []*Finding {
+	var r []*Finding
+	tokens := q.Tokens
+	for i, t := range tokens {
+		if t.kind != TokKeyword || t.text != "SELECT" || t.depth != 0 {
+			continue
+		}
+		for j := i + 1; j < len(tokens); j++ {
+			c := tokens[j]
+			if c.depth < t.depth || (c.depth == t.depth && c.kind == TokKeyword && c.text == "FROM") {
+				break
+			}
+			if c.depth != t.depth || c.kind != TokOperator || c.text != "*" {
+				continue
+			}
+			prev := tokens[j-1]
+			if prev.kind == TokKeyword || (prev.kind == TokPunct && (prev.text == "," || prev.text == ".")) {
+				r = append(r, &Finding{
+					Message:     "exist select * or select (x).*",
+					Pos:         q.Position(c.pos),
+					Severity:    SeverityLow,
+					Remediation: "list the columns explicitly",
+				})
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,165 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// builderTypes 按顺序写入拼接sql的类型
+var builderTypes = map[string]bool{
+	"strings.Builder": true,
+	"bytes.Buffer":    true,
+}
+
+// isBuilderType 类型是否为 strings.Builder、bytes.Buffer 或它们的指针
+func isBuilderType(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil {
+		return false
+	}
+	return builderTypes[n.Obj().Pkg().Path()+"."+n.Obj().Name()]
+}
+
+// builderName 表达式是builder时返回它在 allPossibleInput 中的名字，&sb 与 sb 相同
+func (si *Analyzer) builderName(e ast.Expr) string {
+	if u, ok := e.(*ast.UnaryExpr); ok {
+		e = u.X
+	}
+	name := exprName(e)
+	if name == "" {
+		return ""
+	}
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if t := si.pkg.TypesInfo.TypeOf(e); t != nil {
+			if isBuilderType(t) {
+				return name
+			}
+			return ""
+		}
+	}
+	if si.builders[name] {
+		return name
+	}
+	return ""
+}
+
+// isBuilderInit 没有类型信息时，根据 var sb strings.Builder、&bytes.Buffer{}、new(bytes.Buffer) 等声明识别builder
+func isBuilderInit(e ast.Expr) bool {
+	switch t := e.(type) {
+	case *ast.StarExpr:
+		return isBuilderInit(t.X)
+	case *ast.UnaryExpr:
+		return isBuilderInit(t.X)
+	case *ast.CompositeLit:
+		return isBuilderInit(t.Type)
+	case *ast.CallExpr:
+		if id, ok := t.Fun.(*ast.Ident); ok && id.Name == "new" && len(t.Args) == 1 {
+			return isBuilderInit(t.Args[0])
+		}
+		if sel, ok := t.Fun.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == "bytes" {
+				return sel.Sel.Name == "NewBufferString" || sel.Sel.Name == "NewBuffer"
+			}
+		}
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			return builderTypes[x.Name+"."+t.Sel.Name]
+		}
+	}
+	return false
+}
+
+// recordBuilderDecl 记录 var sb strings.Builder 形式声明的builder
+func (si *Analyzer) recordBuilderDecl(vs *ast.ValueSpec) {
+	for i, name := range vs.Names {
+		if (vs.Type != nil && isBuilderInit(vs.Type)) ||
+			(i < len(vs.Values) && isBuilderInit(vs.Values[i])) {
+			si.builders[name.Name] = true
+			delete(si.allPossibleInput, name.Name)
+		}
+	}
+}
+
+// recordBuilderAssign 记录 sb := &strings.Builder{} 形式的builder
+func (si *Analyzer) recordBuilderAssign(node *ast.AssignStmt) {
+	for i, lhs := range node.Lhs {
+		if i < len(node.Rhs) && isBuilderInit(node.Rhs[i]) {
+			if name := exprName(lhs); name != "" {
+				si.builders[name] = true
+			}
+		}
+	}
+}
+
+// appendBuilder 向builder追加内容
+func (si *Analyzer) appendBuilder(name string, di *DbInput) {
+	if di.Empty() {
+		return
+	}
+	if cur, ok := si.allPossibleInput[name]; ok && !cur.Empty() {
+		si.allPossibleInput[name] = cur.add(di)
+	} else {
+		si.allPossibleInput[name] = di
+	}
+}
+
+// builderWrite 处理 sb.WriteString(x)、sb.WriteByte(c)、sb.WriteRune(r)、sb.Write(b)、sb.Reset()、
+// fmt.Fprintf(&sb, ...)、fmt.Fprint(&sb, ...) 与 io.WriteString(&sb, x)
+func (si *Analyzer) builderWrite(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	if x, ok := sel.X.(*ast.Ident); ok && (x.Name == "fmt" || x.Name == "io") && len(call.Args) > 0 {
+		name := si.builderName(call.Args[0])
+		if name == "" {
+			return false
+		}
+		switch x.Name + "." + sel.Sel.Name {
+		case "fmt.Fprintf":
+			si.appendBuilder(name, si.getDbInputFromSprintf(call.Args[1:]))
+		case "fmt.Fprint":
+			for _, arg := range call.Args[1:] {
+				si.appendBuilder(name, si.getDbInputFromRhs(arg))
+			}
+		case "io.WriteString":
+			if len(call.Args) == 2 {
+				si.appendBuilder(name, si.getDbInputFromRhs(call.Args[1]))
+			}
+		default:
+			return false
+		}
+		return true
+	}
+	name := si.builderName(sel.X)
+	if name == "" {
+		return false
+	}
+	switch sel.Sel.Name {
+	case "WriteString", "WriteByte", "WriteRune", "Write":
+		if len(call.Args) == 1 {
+			si.appendBuilder(name, si.getDbInputFromRhs(call.Args[0]))
+		}
+	case "Reset":
+		delete(si.allPossibleInput, name)
+	default:
+		return false
+	}
+	return true
+}
+
+// getDbInputFromBuilder sb.String()、buf.Bytes() 得到builder中已经写入的内容，不是builder时返回nil
+func (si *Analyzer) getDbInputFromBuilder(call *ast.CallExpr) *DbInput {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || len(call.Args) != 0 || (sel.Sel.Name != "String" && sel.Sel.Name != "Bytes") {
+		return nil
+	}
+	name := si.builderName(sel.X)
+	if name == "" {
+		return nil
+	}
+	return si.getDbInputFromToken(name)
+}
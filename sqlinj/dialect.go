@@ -0,0 +1,271 @@
+package sqlinj
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dialect 数据库方言使用的绑定参数占位符
+type Dialect struct {
+	name     string
+	question bool // ?，sqlite 还有 ?NNN
+	dollar   bool // $1，sqlite 还有 $name
+	colon    bool // :name、:1
+	at       bool // @p1、@name
+	dquote   bool // " 是字符串而不是带引号的标识符
+	escape   bool // 字符串中的 \ 是转义
+	hash     bool // # 开始行注释
+}
+
+// Name 方言的名字，如 mysql
+func (d *Dialect) Name() string {
+	return d.name
+}
+
+var dialects = map[string]*Dialect{
+	"mysql":     {name: "mysql", question: true, dquote: true, escape: true, hash: true},
+	"postgres":  {name: "postgres", dollar: true},
+	"sqlite":    {name: "sqlite", question: true, dollar: true, colon: true, at: true},
+	"oracle":    {name: "oracle", colon: true},
+	"sqlserver": {name: "sqlserver", at: true},
+}
+
+// defaultDialect 没有为数据库调用配置方言时使用，-dialect 参数可以修改
+var defaultDialect = dialects["mysql"]
+
+// sinkDialects 数据库调用的类型使用的方言，-sink-dialects 参数可以追加
+var sinkDialects = map[string]*Dialect{}
+
+// SetDialects 设置默认方言以及逗号分隔的 类型=方言，如 *sqlx.DB=postgres
+func SetDialects(name string, list string) {
+	if d, ok := dialects[strings.ToLower(strings.TrimSpace(name))]; ok {
+		defaultDialect = d
+	}
+	for _, item := range strings.Split(list, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if d, ok := dialects[strings.ToLower(strings.TrimSpace(kv[1]))]; ok {
+			sinkDialects[strings.TrimSpace(kv[0])] = d
+		}
+	}
+}
+
+// dialectOf 数据库调用的类型使用的方言
+func dialectOf(iType string) *Dialect {
+	if d, ok := sinkDialects[iType]; ok {
+		return d
+	}
+	return defaultDialect
+}
+
+// marker s[i] 开始的占位符的长度，不是占位符时为0；prev 为前一个字符，
+// 标识符中的 $、postgres 的 ::type 与 sqlserver 的 @@var 不是占位符
+func (d *Dialect) marker(s string, i int, prev byte) int {
+	d = d.orDefault()
+	c := s[i]
+	n := 1
+	for i+n < len(s) && isWordChar(s[i+n]) && s[i+n] != '$' {
+		n++
+	}
+	switch c {
+	case '?':
+		if !d.question {
+			return 0
+		}
+		// ?NNN
+		for n = 1; i+n < len(s) && s[i+n] >= '0' && s[i+n] <= '9'; n++ {
+		}
+		return n
+	case '$':
+		if d.dollar && n > 1 && !isWordChar(prev) && (d.colon || isDigits(s[i+1:i+n])) {
+			return n
+		}
+	case ':':
+		if d.colon && n > 1 && prev != ':' && !isWordChar(prev) {
+			return n
+		}
+	case '@':
+		if d.at && n > 1 && prev != '@' && !isWordChar(prev) {
+			return n
+		}
+	}
+	return 0
+}
+
+// orDefault 方言为空时使用默认方言
+func (d *Dialect) orDefault() *Dialect {
+	if d == nil {
+		return defaultDialect
+	}
+	return d
+}
+
+// stringQuote c 开始一个字符串，mysql 中 " 也是字符串
+func (d *Dialect) stringQuote(c byte) bool {
+	return c == '\'' || c == '"' && d.orDefault().dquote
+}
+
+// escapes 字符串中的 \ 是否为转义
+func (d *Dialect) escapes() bool {
+	return d.orDefault().escape
+}
+
+// hashComment # 是否开始行注释，只有 mysql 是
+func (d *Dialect) hashComment() bool {
+	return d.orDefault().hash
+}
+
+// questionMark ? 是否为占位符，postgres 中 ? 是 jsonb 的运算符
+func (d *Dialect) questionMark() bool {
+	return d.orDefault().question
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// chainSlots follow链每个片段中使用参数的动词以及绑定参数的占位符，按出现的顺序；
+// 占位符的 verb 为 '?'，引号与注释中的不算，词法状态在片段之间延续
+func (di *DbInput) chainSlots(d *Dialect) [][]fmtVerb {
+	lx := &sqlLexer{dialect: d}
+	var r [][]fmtVerb
+	for l := di; l != nil; l = l.follow {
+		r = append(r, lx.slotsIn(l.format, d))
+	}
+	return r
+}
+
+// slotsIn 扫描一个片段中的动词与占位符
+func (lx *sqlLexer) slotsIn(format string, d *Dialect) []fmtVerb {
+	vs := verbs(format)
+	var r []fmtVerb
+	j := 0
+	for i := 0; i < len(format); i++ {
+		if j < len(vs) && i == vs[j].start {
+			r = append(r, vs[j])
+			i = vs[j].end - 1
+			j++
+			continue
+		}
+		if lx.state == lexNormal {
+			prev := byte(' ')
+			if i > 0 {
+				prev = format[i-1]
+			} else if lx.cur != nil && len(lx.buf) > 0 {
+				prev = lx.buf[len(lx.buf)-1]
+			}
+			if n := d.marker(format, i, prev); n > 0 {
+				lx.endWord()
+				r = append(r, fmtVerb{start: i, end: i + n, verb: '?', arg: -1})
+				lx.emit(TokMarker, format[i:i+n], token.NoPos)
+				i = i + n - 1
+				continue
+			}
+		}
+		i = lx.char(format, i)
+	}
+	return r
+}
+
+// placeholderCount 常量sql需要的参数个数：? 每个一个参数，带编号的占位符取最大的编号，
+// 命名的占位符按名字去重；sql中有动态的部分或者残留的动词时无法确定
+func (di *DbInput) placeholderCount(d *Dialect) (int, bool) {
+	if di.isCollection() || di.prepare != nil {
+		return 0, false
+	}
+	for l := di; l != nil; l = l.follow {
+		if len(l.paras) > 0 || l.prepare != nil {
+			return 0, false
+		}
+	}
+	count, maxNum := 0, 0
+	names := map[string]bool{}
+	ss := di.chainSlots(d)
+	k := 0
+	for l := di; l != nil; l = l.follow {
+		for _, s := range ss[k] {
+			if s.verb != '?' {
+				return 0, false
+			}
+			text := l.format[s.start:s.end]
+			num := strings.TrimLeft(text[1:], "p")
+			switch {
+			case text == "?":
+				count++
+			case isDigits(num):
+				if n, _ := strconv.Atoi(num); n > maxNum {
+					maxNum = n
+				}
+			default:
+				names[text[1:]] = true
+			}
+		}
+		k++
+	}
+	return count + maxNum + len(names), true
+}
+
+// argCount 数据库调用的占位符个数与传入的绑定参数个数，不同路径上的个数不同时无法确定
+type argCount struct {
+	call     *ast.CallExpr
+	fun      string
+	expected int
+	actual   int
+	dynamic  bool
+}
+
+// checkArgCount 记录每条路径上占位符的个数，函数分析结束时报告
+func (si *Analyzer) checkArgCount(ce *ast.CallExpr, index int, format *DbInput) {
+	if si.quiet {
+		return
+	}
+	expected, ok := format.placeholderCount(si.dialect)
+	c, found := si.argCounts[ce.Pos()]
+	if !found {
+		c = &argCount{
+			call:     ce,
+			fun:      si.curFunName,
+			expected: expected,
+			actual:   len(ce.Args) - index - 1,
+		}
+		si.argCounts[ce.Pos()] = c
+	}
+	// args... 的个数在运行时才知道
+	if !ok || ce.Ellipsis.IsValid() || c.expected != expected {
+		c.dynamic = true
+	}
+}
+
+// reportArgCounts 占位符的个数与传入的绑定参数个数不一致时，运行时会出错
+func (si *Analyzer) reportArgCounts() {
+	positions := make([]token.Pos, 0, len(si.argCounts))
+	for pos := range si.argCounts {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	for _, pos := range positions {
+		c := si.argCounts[pos]
+		if c.dynamic || c.expected == c.actual {
+			continue
+		}
+		si.report(pos, &Finding{
+			Rule:        RuleArgCount,
+			Func:        c.fun,
+			Message:     fmt.Sprintf("placeholder count mismatch: expected %d bind args, got %d", c.expected, c.actual),
+			Pos:         si.position(pos),
+			Severity:    SeverityMedium,
+			Remediation: "pass exactly one bind argument for each placeholder",
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package sqlinj
+
+import "testing"
+
+func TestPlaceholderCount(t *testing.T) {
+	tests := []struct {
+		format  string
+		dialect string
+		want    int
+		ok      bool
+	}{
+		{"SELECT a FROM t WHERE b = ? AND c = ?", "mysql", 2, true},
+		{"SELECT a FROM t WHERE b = '?' AND c = ? -- ?", "mysql", 1, true},
+		{"SELECT a FROM t WHERE b = $1 AND c = $2 OR d = $1", "postgres", 2, true},
+		{"SELECT a::text FROM t WHERE b = $1", "postgres", 1, true},
+		{"SELECT a FROM t WHERE b = ?", "postgres", 0, true},
+		{"SELECT a FROM t WHERE b = :name AND c = :name AND d = :other", "oracle", 2, true},
+		{"SELECT a FROM t WHERE b = @p1 AND c = @p2 AND @@ROWCOUNT > 0", "sqlserver", 2, true},
+		{"SELECT a FROM t WHERE b = ?2 AND c = ?1", "sqlite", 2, true},
+		{"SELECT a FROM t WHERE b = %d", "mysql", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := (&DbInput{format: tt.format}).placeholderCount(dialects[tt.dialect])
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s (%s): got %d %v, want %d %v", tt.format, tt.dialect, got, ok, tt.want, tt.ok)
+		}
+	}
+}
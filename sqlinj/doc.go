@@ -0,0 +1,35 @@
+/*
+Package sqlinj 分析Go代码中到达数据库调用的sql，报告sql注入以及其他规则发现的问题。
+
+命令行工具在仓库根目录的 main.go 中，其他程序可以直接使用分析器：
+
+	sqlinj.ConfigureRules("select-asterisk")
+	si := sqlinj.NewAnalyzer("")
+	si.CheckDir("./...")
+	for _, f := range si.Findings() {
+		fmt.Println(f)
+	}
+
+自定义规则实现 Rule 接口，并在使用分析器之前注册。Check 对每条路径上到达数据库调用的sql调用一次，
+Query.Tokens 是重建的sql的记号，Query.Tainted 是拼接进sql的函数参数：
+
+	type noUnion struct{}
+
+	func (noUnion) ID() string { return "no-union" }
+
+	func (noUnion) Check(q *sqlinj.Query) []*sqlinj.Finding {
+		for _, t := range q.Tokens {
+			if t.Kind() == sqlinj.TokKeyword && t.Text() == "UNION" {
+				return []*sqlinj.Finding{{Message: "UNION in query", Pos: q.Position(t.Pos()), Severity: sqlinj.SeverityLow}}
+			}
+		}
+		return nil
+	}
+
+	func init() {
+		sqlinj.RegisterRule(noUnion{})
+	}
+
+Finding 的 Rule 与 Func 为空时使用规则的ID与数据库调用所在的函数，同一个调用上相同位置的问题只报告一次。
+*/
+package sqlinj
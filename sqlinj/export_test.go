@@ -0,0 +1,8 @@
+package sqlinj
+
+import "golang.org/x/tools/go/packages"
+
+// LoadFixture 供外部测试包读取 testdata 中的包
+func LoadFixture(name string) (*packages.Package, error) {
+	return newFixtureLoader().load("fixture/" + name)
+}
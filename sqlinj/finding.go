@@ -0,0 +1,96 @@
+package sqlinj
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// 规则的ID
+const (
+	RuleSQLInjection   = "sql-injection"
+	RuleSelectAsterisk = "select-asterisk"
+	RuleArgCount       = "placeholder-count"
+	RuleMissingWhere   = "missing-where"
+	RuleSchema         = "schema"
+	RuleIdentifier     = "dynamic-identifier"
+	RuleLikeWildcard   = "like-wildcard"
+)
+
+// 问题的严重程度
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// Finding 分析发现的一个问题
+type Finding struct {
+	Rule        string         `json:"rule"`
+	Func        string         `json:"func"`
+	Message     string         `json:"message"`
+	Pos         token.Position `json:"pos"`
+	Context     string         `json:"context,omitempty"`
+	Severity    string         `json:"severity,omitempty"`
+	Remediation string         `json:"remediation,omitempty"`
+}
+
+// String 与原来的错误输出保持一致，之后附加上下文、严重程度与修复建议
+func (f *Finding) String() string {
+	s := f.Func + " " + f.Message
+	if f.Pos.IsValid() {
+		s = s + " at " + f.Pos.String()
+	}
+	if f.Severity != "" {
+		s = s + " [" + f.Severity
+		if f.Context != "" {
+			s = s + " " + f.Context
+		}
+		s = s + "]"
+	}
+	if f.Remediation != "" {
+		s = s + " " + f.Remediation
+	}
+	return s
+}
+
+// contextRank 上下文的严重程度与修复建议，rank 越小越严重
+var contextRank = map[string]struct {
+	rank        int
+	severity    string
+	remediation string
+}{
+	ctxExpression:       {0, SeverityCritical, "build the clause from constant fragments and bind the values as parameters"},
+	ctxKeyword:          {1, SeverityHigh, "map the input to a fixed set of keywords or operators"},
+	ctxIdentifier:       {2, SeverityHigh, "identifiers cannot be bound as parameters, check the identifier against an allowlist or use a quoted-identifier helper"},
+	ctxValue:            {3, SeverityHigh, "pass the value as a bind parameter"},
+	ctxStringLiteral:    {4, SeverityHigh, "pass the value as a bind parameter instead of quoting it in the sql"},
+	ctxQuotedIdentifier: {5, SeverityMedium, "check the identifier against an allowlist or escape the quote character with a quoted-identifier helper"},
+	ctxComment:          {6, SeverityLow, "keep the input out of sql comments"},
+}
+
+// findingKey 同一个调用上同一个规则在同一个位置的问题只报告一次
+type findingKey struct {
+	rule string
+	call token.Pos
+	at   token.Position
+}
+
+// report 记录发现的问题，多条路径到达同一个调用时只报告一次
+func (si *Analyzer) report(call token.Pos, f *Finding) {
+	k := findingKey{rule: f.Rule, call: call, at: f.Pos}
+	if si.reported[k] {
+		return
+	}
+	si.reported[k] = true
+	fmt.Println(f)
+	si.result = append(si.result, f)
+}
+
+// position 源码中的位置，没有包的信息时位置未知
+func (si *Analyzer) position(pos token.Pos) token.Position {
+	if si.pkg == nil || !pos.IsValid() {
+		return token.Position{}
+	}
+	return si.pkg.Fset.Position(pos)
+}
@@ -0,0 +1,321 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// fixtureLoader 从 testdata 读取 fixture/ 开头的包，标准库从源码导入
+type fixtureLoader struct {
+	fset *token.FileSet
+	std  types.Importer
+	pkgs map[string]*packages.Package
+}
+
+func newFixtureLoader() *fixtureLoader {
+	fset := token.NewFileSet()
+	return &fixtureLoader{
+		fset: fset,
+		std:  importer.ForCompiler(fset, "source", nil),
+		pkgs: make(map[string]*packages.Package),
+	}
+}
+
+func (fl *fixtureLoader) Import(path string) (*types.Package, error) {
+	if !strings.HasPrefix(path, "fixture/") {
+		return fl.std.Import(path)
+	}
+	pkg, err := fl.load(path)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.Types, nil
+}
+
+func (fl *fixtureLoader) load(path string) (*packages.Package, error) {
+	if pkg, ok := fl.pkgs[path]; ok {
+		return pkg, nil
+	}
+	names, err := filepath.Glob(filepath.Join("testdata", strings.TrimPrefix(path, "fixture/"), "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, name := range names {
+		f, err := parser.ParseFile(fl.fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Implicits:  make(map[ast.Node]types.Object),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	conf := types.Config{Importer: fl}
+	tp, err := conf.Check(path, fl.fset, files, info)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &packages.Package{
+		ID:        path,
+		Name:      tp.Name(),
+		PkgPath:   path,
+		GoFiles:   names,
+		Fset:      fl.fset,
+		Syntax:    files,
+		Types:     tp,
+		TypesInfo: info,
+		Imports:   make(map[string]*packages.Package),
+	}
+	for _, f := range files {
+		for _, im := range f.Imports {
+			ip, _ := strconv.Unquote(im.Path.Value)
+			if strings.HasPrefix(ip, "fixture/") {
+				if pkg.Imports[ip], err = fl.load(ip); err != nil {
+					return nil, err
+				}
+			} else {
+				pkg.Imports[ip] = &packages.Package{ID: ip, PkgPath: ip}
+			}
+		}
+	}
+	fl.pkgs[path] = pkg
+	return pkg, nil
+}
+
+// loadFixture 读取 testdata 中的包
+func loadFixture(t *testing.T, name string) *packages.Package {
+	t.Helper()
+	pkg, err := newFixtureLoader().load("fixture/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+// checkFixture 分析 testdata 中的包，返回 函数名 规则ID 行号 形式的结果
+func checkFixture(t *testing.T, name string) []string {
+	t.Helper()
+	si := NewAnalyzer("")
+	si.checkPackages([]*packages.Package{loadFixture(t, name)}, nil)
+	var r []string
+	for _, f := range si.result {
+		r = append(r, f.Func+" "+f.Rule+" "+strconv.Itoa(f.Pos.Line))
+	}
+	sort.Strings(r)
+	return r
+}
+
+func TestFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		rules   string // 运行前关闭的规则，如 -dynamic-identifier
+		want    []string
+	}{
+		{"group", "", []string{
+			"Grouped sql-injection 15",
+			"Unnamed sql-injection 32",
+			"sink sql-injection 20",
+		}},
+		{"arity", "", []string{
+			"Variadic sql-injection 30",
+		}},
+		{"san", "", []string{
+			"Bad sql-injection 42",
+			"Custom sql-injection 37",
+		}},
+		// 数值字段与 sqlinject:"trusted" 标记的字段不报告，字段被常量覆盖之后不再被污染
+		{"field", "", []string{
+			"Both sql-injection 42",
+			"Name sql-injection 28",
+		}},
+		{"builder", "", []string{
+			"SB dynamic-identifier 15",
+			"Where sql-injection 41",
+		}},
+		// ReplaceConst 与 Split 中的 parts[1] 只有常量
+		{"std", "", []string{
+			"Replace sql-injection 20",
+			"Split dynamic-identifier 35",
+			"Split sql-injection 36",
+			"Sprintf sql-injection 43",
+			"Upper sql-injection 13",
+		}},
+		// 各分支的片段在汇合处合并，被覆盖的值不再报告
+		{"branch", "", []string{
+			"Closure sql-injection 60",
+			"Switched dynamic-identifier 43",
+			"TaintFirst sql-injection 9",
+			"TaintLast sql-injection 22",
+		}},
+		// 循环迭代到不动点，range 变量绑定到元素
+		{"loop", "", []string{
+			"Conds sql-injection 36",
+			"Filters sql-injection 19",
+			"Later sql-injection 48",
+		}},
+		// var/const 声明、多值赋值与多返回值
+		{"decl", "", []string{
+			"Const sql-injection 13",
+			"Multi sql-injection 30",
+			"Tuple sql-injection 52",
+			"VarDecl sql-injection 24",
+			"Wrapped sql-injection 69",
+		}},
+		// 字面量中的转义、原始字符串和字符
+		{"lit", "", []string{
+			"Escaped sql-injection 12",
+			"Raw sql-injection 18",
+			"Runes sql-injection 31",
+		}},
+		// 按 fmt 的语法解析 Sprintf 的动词与参数下标
+		{"fmtv", "", []string{
+			"Args sql-injection 46",
+			"Index sql-injection 16",
+			"Q sql-injection 36",
+			"V sql-injection 31",
+			"Width sql-injection 26",
+		}},
+		// 按元素个数生成的 IN 列表占位符
+		{"inlist", "", []string{
+			"Unsafe sql-injection 56",
+			"UnsafeFill sql-injection 62",
+		}},
+		// string、[]byte、[]rune 与命名字符串类型之间的转换
+		{"conv", "", []string{
+			"Bytes sql-injection 11",
+			"Named sql-injection 24",
+			"Param sql-injection 36",
+			"RoundTrip sql-injection 17",
+		}},
+		// 占位符与 %s 混用时参数跟随各自的动词
+		{"mix", "", []string{
+			"Mix sql-injection 11",
+		}},
+		// 某条路径上的 UPDATE 或 DELETE 没有有效的 WHERE
+		{"where", "", []string{
+			"All missing-where 10",
+			"Conds missing-where 34",
+			"Dynamic sql-injection 50",
+			"OneEqOne missing-where 38",
+			"Optional missing-where 26",
+			"Sub missing-where 18",
+		}},
+		// UNION 只由外部规则检查
+		{"union", "", nil},
+		// 模板中的 {{.Name}} 来自参数，Local 中只有数值
+		{"tpl", "", []string{
+			"List sql-injection 35",
+			"Report sql-injection 28",
+		}},
+		// 模式不是首尾锚定、只匹配字母数字的常量时不算校验
+		{"guard", "", []string{
+			"Alternation dynamic-identifier 99",
+			"Dash dynamic-identifier 107",
+			"DotPlus dynamic-identifier 90",
+			"DotStar dynamic-identifier 82",
+			"DynamicPattern dynamic-identifier 115",
+			"LocalAny dynamic-identifier 134",
+			"LooseRe dynamic-identifier 74",
+			"MapBody dynamic-identifier 32",
+		}},
+		// 标识符位置的参数单独报告，字符串枚举与常量map中的值不报告
+		{"ident", "", []string{
+			"DynMap dynamic-identifier 37",
+			"Order dynamic-identifier 20",
+		}},
+		// dynamic-identifier 关闭时标识符位置的参数仍然报告为 sql-injection
+		{"ident", "-dynamic-identifier", []string{
+			"DynMap sql-injection 37",
+			"Order sql-injection 20",
+		}},
+		// 绑定到 LIKE 模式上的参数需要转义通配符
+		{"like", "", []string{
+			"Bound like-wildcard 15",
+			"Custom like-wildcard 49",
+			"Index like-wildcard 25",
+			"Spread like-wildcard 31",
+			"Wrapped like-wildcard 20",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture+tt.rules, func(t *testing.T) {
+			if tt.rules != "" {
+				ConfigureRules(tt.rules)
+				defer ConfigureRules(strings.TrimPrefix(tt.rules, "-"))
+			}
+			got := checkFixture(t, tt.fixture)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+// 结果指向注入的值在源码中的位置
+func TestFindingPosition(t *testing.T) {
+	si := NewAnalyzer("")
+	si.checkPackages([]*packages.Package{loadFixture(t, "lit")}, nil)
+	want := []string{
+		"Escaped at testdata/lit/lit.go:12:71",
+		"Raw at testdata/lit/lit.go:18:18",
+		"Runes at testdata/lit/lit.go:31:17",
+	}
+	var got []string
+	for _, f := range si.result {
+		got = append(got, f.Func+" at "+f.Pos.String())
+	}
+	sort.Strings(got)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// *sqlx.Tx 使用 postgres 的 $1 占位符，引号与注释中的 ? 不是占位符；
+// 占位符个数与参数个数不同时报告，args... 与不同分支上个数不同的调用不报告
+func TestSinkDialects(t *testing.T) {
+	SetDialects("", "*sqlx.Tx=postgres")
+	defer delete(sinkDialects, "*sqlx.Tx")
+	got := checkFixture(t, "dia")
+	want := []string{
+		"CommentMark sql-injection 21",
+		"Pg sql-injection 31",
+		"PgGap placeholder-count 56",
+		"QuotedMark sql-injection 16",
+		"TooFew placeholder-count 41",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// -select-asterisk 只检查顶层 SELECT 返回的列
+func TestSelectAsterisk(t *testing.T) {
+	ConfigureRules(RuleSelectAsterisk)
+	defer ConfigureRules("-" + RuleSelectAsterisk)
+	got := checkFixture(t, "star")
+	want := []string{
+		"Alias select-asterisk 14",
+		"Insert select-asterisk 48",
+		"Plain select-asterisk 9",
+		"Union select-asterisk 44",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,370 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/cfg"
+)
+
+// maxStates 每个基本块入口最多保留的路径数，超出时合并到最后一条路径
+const maxStates = 16
+
+// maxVisits 同一个基本块最多分析的次数，循环没有通过加宽收敛时保证分析结束
+const maxVisits = 8
+
+// flowState 一条执行路径上变量的值，与 allPossibleInput 相同
+type flowState map[string]*DbInput
+
+func (fs flowState) copy() flowState {
+	r := make(flowState, len(fs))
+	for k, v := range fs {
+		r[k] = v
+	}
+	return r
+}
+
+// key 用于路径去重
+func (fs flowState) key() string {
+	names := make([]string, 0, len(fs))
+	for name := range fs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	b := &strings.Builder{}
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(fs[name].String())
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+// taint 路径上未净化的参数个数，合并路径时保留污点多的值
+func taint(di *DbInput) int {
+	count := 0
+	n := di
+	for {
+		for l := n; l != nil; l = l.follow {
+			for _, p := range l.paras {
+				if p != nil && !p.sanitized {
+					count++
+				}
+			}
+		}
+		if n.next == nil || n.next == di {
+			break
+		}
+		n = n.next
+	}
+	return count
+}
+
+// units 集合的每个元素或者follow链的每个片段
+func units(di *DbInput) []string {
+	r := []string{}
+	if di.isCollection() {
+		for n := di.next; n != nil && n != di; n = n.next {
+			r = append(r, n.toString())
+		}
+		return r
+	}
+	for l := di; l != nil; l = l.follow {
+		r = append(r, l.toStringSingle())
+	}
+	return r
+}
+
+// repeats 新值是否只是在旧值末尾把最后追加的内容又重复了一次
+func repeats(old, new *DbInput) bool {
+	o, n := units(old), units(new)
+	k := len(n) - len(o)
+	if k <= 0 || k > len(o) {
+		return false
+	}
+	for i := range o {
+		if o[i] != n[i] {
+			return false
+		}
+	}
+	for i := 0; i < k; i++ {
+		if n[len(o)+i] != o[len(o)-k+i] {
+			return false
+		}
+	}
+	return true
+}
+
+// widen 循环头的加宽：变量在循环中重复追加同样的内容时用已有的值代替，循环因此能够收敛
+func widen(states []flowState, st flowState) flowState {
+	r := st
+	copied := false
+	for name, di := range st {
+		for _, old := range states {
+			if o, ok := old[name]; ok && o != di && repeats(o, di) {
+				if !copied {
+					r = st.copy()
+					copied = true
+				}
+				r[name] = o
+				break
+			}
+		}
+	}
+	return r
+}
+
+// addStates 将新的路径加入基本块的入口，返回是否有新路径，loop 为 true 时基本块是循环头
+func addStates(states []flowState, add []flowState, loop bool) ([]flowState, bool) {
+	changed := false
+	for _, st := range add {
+		if loop {
+			st = widen(states, st)
+		}
+		k := st.key()
+		found := false
+		for _, old := range states {
+			if old.key() == k {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		changed = true
+		if len(states) < maxStates {
+			states = append(states, st)
+			continue
+		}
+		// 路径太多时合并，每个变量保留污点最多的值
+		last := states[len(states)-1].copy()
+		for name, di := range st {
+			if old, ok := last[name]; !ok || taint(di) > taint(old) {
+				last[name] = di
+			}
+		}
+		states[len(states)-1] = last
+	}
+	return states, changed
+}
+
+// reversePostorder 基本块的逆后序，循环之外的前驱总是先于后继
+func reversePostorder(g *cfg.CFG) []*cfg.Block {
+	seen := make(map[*cfg.Block]bool)
+	order := []*cfg.Block{}
+	var visit func(b *cfg.Block)
+	visit = func(b *cfg.Block) {
+		seen[b] = true
+		for _, succ := range b.Succs {
+			if !seen[succ] {
+				visit(succ)
+			}
+		}
+		order = append(order, b)
+	}
+	visit(g.Blocks[0])
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// mayReturn 调用之后是否还会继续执行，panic、os.Exit、log.Fatal 等不会返回
+func mayReturn(call *ast.CallExpr) bool {
+	return !neverReturns(call)
+}
+
+// analyzeBody 按控制流图分析函数体，每个基本块的入口是所有到达路径上变量值的集合，
+// 数据库调用在任何一条路径上被污染都会报告
+func (si *Analyzer) analyzeBody(body *ast.BlockStmt, entry []flowState) {
+	g := cfg.New(body, mayReturn)
+	si.indexOwners(body)
+	order := reversePostorder(g)
+	index := make(map[*cfg.Block]int)
+	for i, b := range order {
+		index[b] = i
+	}
+	in := make(map[*cfg.Block][]flowState)
+	in[order[0]] = entry
+	pending := map[*cfg.Block]bool{order[0]: true}
+	visits := make(map[*cfg.Block]int)
+	// 按逆后序取待分析的基本块，直到所有基本块的入口不再变化
+	for {
+		var b *cfg.Block
+		for _, o := range order {
+			if pending[o] {
+				b = o
+				break
+			}
+		}
+		if b == nil {
+			break
+		}
+		pending[b] = false
+		if visits[b] >= maxVisits {
+			continue
+		}
+		visits[b]++
+		out := si.transferBlock(b, in[b])
+		for i, succ := range b.Succs {
+			var changed bool
+			// 回边的目标是循环头
+			in[succ], changed = addStates(in[succ], si.edgeStates(b, i, out), index[succ] <= index[b])
+			if changed {
+				pending[succ] = true
+			}
+		}
+	}
+}
+
+// transferBlock 在每条路径上依次分析基本块中的语句
+func (si *Analyzer) transferBlock(b *cfg.Block, states []flowState) []flowState {
+	out := make([]flowState, 0, len(states))
+	for _, st := range states {
+		si.allPossibleInput = st.copy()
+		for _, n := range b.Nodes {
+			si.transferNode(n)
+		}
+		out = append(out, si.allPossibleInput)
+	}
+	return out
+}
+
+// transferNode 分析基本块中的一个语句或表达式，先分析其中的调用再处理赋值
+func (si *Analyzer) transferNode(n ast.Node) {
+	si.visitCalls(n)
+	switch node := n.(type) {
+	case *ast.AssignStmt:
+		si.assign(node)
+	case *ast.ValueSpec:
+		si.recordBuilderDecl(node)
+		si.declare(node)
+	case *ast.ReturnStmt:
+		if si.summary != nil && si.litDepth == 0 {
+			si.addReturnSummary(node)
+		}
+	case ast.Expr:
+		if rs, ok := si.owners[node].(*ast.RangeStmt); ok {
+			si.bindRange(rs, node)
+		}
+	}
+}
+
+// visitCalls 分析节点中的所有调用，函数字面量的函数体单独按控制流图分析
+func (si *Analyzer) visitCalls(n ast.Node) {
+	ast.Inspect(n, func(x ast.Node) bool {
+		switch node := x.(type) {
+		case *ast.FuncLit:
+			si.analyzeFuncLit(node)
+			return false
+		case *ast.CallExpr:
+			si.handleCall(node)
+		}
+		return true
+	})
+}
+
+// analyzeFuncLit 函数字面量从当前路径的变量值开始分析，分析结果不影响外层函数
+func (si *Analyzer) analyzeFuncLit(lit *ast.FuncLit) {
+	saved := si.allPossibleInput
+	si.litDepth++
+	si.analyzeBody(lit.Body, []flowState{flowState(saved).copy()})
+	si.litDepth--
+	si.allPossibleInput = saved
+}
+
+// indexOwners 记录 if 条件、case 表达式与 range 变量所属的语句，控制流图中只有这些表达式本身
+func (si *Analyzer) indexOwners(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch s := n.(type) {
+		case *ast.IfStmt:
+			si.owners[s.Cond] = s
+		case *ast.SwitchStmt:
+			for _, stmt := range s.Body.List {
+				for _, e := range stmt.(*ast.CaseClause).List {
+					si.owners[e] = s
+				}
+			}
+		case *ast.RangeStmt:
+			if s.Key != nil {
+				si.owners[s.Key] = s
+			}
+			if s.Value != nil {
+				si.owners[s.Value] = s
+			}
+		}
+		return true
+	})
+}
+
+// bindRange range 的变量是被遍历的集合中的元素，集合中的元素取污点最多的一个
+func (si *Analyzer) bindRange(rs *ast.RangeStmt, v ast.Expr) {
+	id, ok := v.(*ast.Ident)
+	if !ok || id.Name == "_" {
+		return
+	}
+	if si.isSafeExpr(id) {
+		si.allPossibleInput[id.Name] = safeParaInput(id.Name)
+		return
+	}
+	x := si.getDbInputFromRhs(rs.X)
+	if !x.isCollection() {
+		if x.Empty() {
+			delete(si.allPossibleInput, id.Name)
+		} else {
+			si.allPossibleInput[id.Name] = x
+		}
+		return
+	}
+	var elem *DbInput
+	for n := x.next; n != nil && n != x; n = n.next {
+		if elem == nil || taint(n) > taint(elem) {
+			elem = n
+		}
+	}
+	if elem == nil {
+		delete(si.allPossibleInput, id.Name)
+		return
+	}
+	si.allPossibleInput[id.Name] = elem.deepclone()
+}
+
+// edgeGuard 条件基本块第i条出边上被白名单校验的变量，Succs[0]为条件成立的分支
+func (si *Analyzer) edgeGuard(b *cfg.Block, i int) string {
+	if len(b.Succs) != 2 || len(b.Nodes) == 0 {
+		return ""
+	}
+	cond, ok := b.Nodes[len(b.Nodes)-1].(ast.Expr)
+	if !ok {
+		return ""
+	}
+	switch s := si.owners[cond].(type) {
+	case *ast.IfStmt:
+		if name, positive := si.guardOf(cond); positive == (i == 0) {
+			return name
+		}
+	case *ast.SwitchStmt:
+		if i == 0 && si.allConst([]ast.Expr{cond}) {
+			return si.switchTagName(s)
+		}
+	}
+	return ""
+}
+
+// edgeStates 出边上的路径，被校验的变量在这条边上视为已净化
+func (si *Analyzer) edgeStates(b *cfg.Block, i int, out []flowState) []flowState {
+	name := si.edgeGuard(b, i)
+	if name == "" {
+		return out
+	}
+	r := make([]flowState, 0, len(out))
+	for _, st := range out {
+		si.allPossibleInput = st.copy()
+		si.allPossibleInput[name] = si.getDbInputFromToken(name).sanitizedCopy()
+		r = append(r, si.allPossibleInput)
+	}
+	return r
+}
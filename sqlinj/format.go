@@ -0,0 +1,125 @@
+package sqlinj
+
+import (
+	"unicode/utf8"
+)
+
+// fmtVerb 格式中的一个动词，如 %-10s、%[2]v、%*d
+type fmtVerb struct {
+	start int  // % 的位置
+	end   int  // 动词之后的位置
+	verb  rune // 动词，%% 为 '%'
+	arg   int  // 动词对应的参数下标，%% 没有参数时为 -1
+}
+
+// parseFormat 按 fmt 的语法解析格式：%[flags][width][.precision]verb，width 与 precision 可以为 *，
+// [n] 指定下一个参数的下标。argNum 为第一个参数的下标，返回所有动词以及之后的参数下标。
+// 与 fmt 一样，flags、width、precision 之后的任何字符都是动词并使用一个参数，fmt 没有定义的 %a、%_ 也是，
+// 所以 sql 中的 '%abc%' 会被当成动词 %a；只有 %% 不使用参数，格式末尾单独的 % 不算动词
+func parseFormat(format string, argNum int) ([]fmtVerb, int) {
+	var r []fmtVerb
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		v, next, ok := parseVerb(format, i, argNum)
+		if !ok {
+			continue
+		}
+		r = append(r, v)
+		argNum = next
+		i = v.end - 1
+	}
+	return r, argNum
+}
+
+// parseVerb 解析从 start 开始的一个动词
+func parseVerb(format string, start int, argNum int) (fmtVerb, int, bool) {
+	v := fmtVerb{start: start, arg: -1}
+	i := start + 1
+	// flags
+	for i < len(format) && isFmtFlag(format[i]) {
+		i++
+	}
+	// [n] 与 width
+	i, argNum = parseArgIndex(format, i, argNum)
+	if i < len(format) && format[i] == '*' {
+		i++
+		argNum++
+	} else {
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++
+		}
+	}
+	// precision
+	if i < len(format) && format[i] == '.' {
+		i++
+		i, argNum = parseArgIndex(format, i, argNum)
+		if i < len(format) && format[i] == '*' {
+			i++
+			argNum++
+		} else {
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
+			}
+		}
+	}
+	i, argNum = parseArgIndex(format, i, argNum)
+	if i >= len(format) {
+		return v, argNum, false
+	}
+	c, size := utf8.DecodeRuneInString(format[i:])
+	v.verb = c
+	v.end = i + size
+	if c == '%' {
+		return v, argNum, true
+	}
+	v.arg = argNum
+	return v, argNum + 1, true
+}
+
+// parseArgIndex 解析 [n]，n 从1开始
+func parseArgIndex(format string, i int, argNum int) (int, int) {
+	if i >= len(format) || format[i] != '[' {
+		return i, argNum
+	}
+	n := 0
+	j := i + 1
+	for ; j < len(format) && format[j] >= '0' && format[j] <= '9'; j++ {
+		n = n*10 + int(format[j]-'0')
+	}
+	if j >= len(format) || format[j] != ']' || j == i+1 || n == 0 {
+		return i, argNum
+	}
+	return j + 1, n - 1
+}
+
+func isFmtFlag(c byte) bool {
+	return c == '+' || c == '-' || c == '#' || c == ' ' || c == '0'
+}
+
+// verbs 格式中使用参数的动词，%% 不算
+func verbs(format string) []fmtVerb {
+	all, _ := parseFormat(format, 0)
+	var r []fmtVerb
+	for _, v := range all {
+		if v.arg >= 0 {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// lastVerb 片段最后一个使用参数的动词，拆分后的片段以动词结尾
+func (di *DbInput) lastVerb() (fmtVerb, bool) {
+	vs := verbs(di.format)
+	if len(vs) == 0 {
+		return fmtVerb{}, false
+	}
+	return vs[len(vs)-1], true
+}
+
+// injectable 动词输出参数本身的内容，%v 对字符串与 %s 相同
+func injectable(verb rune) bool {
+	return verb == 's' || verb == 'v'
+}
@@ -0,0 +1,38 @@
+package sqlinj
+
+import "testing"
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		verbs  string // 使用参数的动词
+		args   int    // 使用的参数个数
+	}{
+		{"a = %s AND b = %d", "sd", 2},
+		{"a LIKE '100%%'", "", 0},
+		{"%-10s %+.2f %x", "sfx", 3},
+		{"%[2]s %[1]s", "ss", 1},
+		{"%*d", "d", 2},
+		{"%.*s", "s", 2},
+		// fmt 没有定义的动词也使用一个参数
+		{"a LIKE '%abc%'", "a'", 2},
+		{"a LIKE '%_'", "_", 1},
+		{"a = %!", "!", 1},
+		{"a LIKE '%'", "'", 1},
+		{"a LIKE '% off'", "o", 1},
+		// 末尾单独的 % 不是动词
+		{"a = 100%", "", 0},
+	}
+	for _, tt := range tests {
+		vs, args := parseFormat(tt.format, 0)
+		got := ""
+		for _, v := range vs {
+			if v.arg >= 0 {
+				got += string(v.verb)
+			}
+		}
+		if got != tt.verbs || args != tt.args {
+			t.Errorf("%s: got %q %d, want %q %d", tt.format, got, args, tt.verbs, tt.args)
+		}
+	}
+}
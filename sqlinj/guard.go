@@ -0,0 +1,288 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// sanitizedCopy 所有参数都标记为已净化的拷贝，常量片段保持不变
+func (di *DbInput) sanitizedCopy() *DbInput {
+	if di.isCollection() {
+		return di
+	}
+	r := di.deepclone()
+	for l := r; l != nil; l = l.follow {
+		paras := make([]*Param, len(l.paras))
+		for i, p := range l.paras {
+			if p != nil {
+				c := *p
+				c.sanitized = true
+				paras[i] = &c
+			}
+		}
+		l.paras = paras
+	}
+	return r
+}
+
+// recordGuardVar 记录 _, ok := m[k] 与 ok := re.MatchString(k) 形式的校验结果变量
+func (si *Analyzer) recordGuardVar(node *ast.AssignStmt) {
+	if len(node.Rhs) != 1 {
+		return
+	}
+	var ok ast.Expr
+	switch rhs := node.Rhs[0].(type) {
+	case *ast.IndexExpr:
+		if len(node.Lhs) == 2 && si.isMap(rhs.X) {
+			ok = node.Lhs[1]
+		}
+	case *ast.CallExpr:
+		if si.matchStringArg(rhs) != nil {
+			ok = node.Lhs[0]
+		}
+	}
+	if id, isIdent := ok.(*ast.Ident); isIdent && id.Name != "_" {
+		si.guardVars[id.Name] = node.Rhs[0]
+	}
+}
+
+// guardOf 条件表达式校验的变量名，positive 为 true 表示条件成立时变量在白名单内
+func (si *Analyzer) guardOf(cond ast.Expr) (string, bool) {
+	switch c := cond.(type) {
+	case *ast.ParenExpr:
+		return si.guardOf(c.X)
+	case *ast.UnaryExpr:
+		if c.Op == token.NOT {
+			name, positive := si.guardOf(c.X)
+			return name, !positive
+		}
+	case *ast.BinaryExpr:
+		if c.Op == token.LAND {
+			if name, positive := si.guardOf(c.X); name != "" && positive {
+				return name, true
+			}
+			if name, positive := si.guardOf(c.Y); name != "" && positive {
+				return name, true
+			}
+		} else if c.Op == token.LOR {
+			if name, positive := si.guardOf(c.X); name != "" && !positive {
+				return name, false
+			}
+			if name, positive := si.guardOf(c.Y); name != "" && !positive {
+				return name, false
+			}
+		}
+	case *ast.Ident:
+		if e, ok := si.guardVars[c.Name]; ok {
+			if index, ok := e.(*ast.IndexExpr); ok {
+				return exprName(index.Index), true
+			}
+			return si.guardOf(e)
+		}
+	case *ast.IndexExpr:
+		if si.isMap(c.X) {
+			return exprName(c.Index), true
+		}
+	case *ast.CallExpr:
+		if arg := si.matchStringArg(c); arg != nil {
+			return exprName(arg), true
+		}
+	}
+	return "", false
+}
+
+// matchStringArg regexp.MatchString(pattern, s) 或 re.MatchString(s) 中被校验的参数，
+// 模式必须是常量并且只能匹配白名单中的字符，找不到模式时不算校验
+func (si *Analyzer) matchStringArg(call *ast.CallExpr) ast.Expr {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "MatchString" {
+		return nil
+	}
+	if x, ok := sel.X.(*ast.Ident); ok && x.Name == "regexp" && len(call.Args) == 2 {
+		if pattern, ok := si.constArg(call.Args[0]); ok && isAllowlistPattern(pattern) {
+			return call.Args[1]
+		}
+		return nil
+	}
+	if len(call.Args) != 1 {
+		return nil
+	}
+	if pattern, ok := si.regexpPattern(sel.X); ok && isAllowlistPattern(pattern) {
+		return call.Args[0]
+	}
+	return nil
+}
+
+// regexpPattern 找到 regexp.MustCompile("...") 的模式，re 可以是调用本身也可以是由它初始化的局部变量或包级变量
+func (si *Analyzer) regexpPattern(re ast.Expr) (string, bool) {
+	switch t := re.(type) {
+	case *ast.ParenExpr:
+		return si.regexpPattern(t.X)
+	case *ast.CallExpr:
+		if sel, ok := t.Fun.(*ast.SelectorExpr); ok && len(t.Args) == 1 &&
+			(sel.Sel.Name == "MustCompile" || sel.Sel.Name == "Compile") {
+			return si.constArg(t.Args[0])
+		}
+	case *ast.Ident:
+		if init, ok := si.localExprs[t.Name]; ok {
+			return si.regexpPattern(init)
+		}
+		if vs, _, i := si.varSpec(t); vs != nil && i < len(vs.Values) {
+			return si.regexpPattern(vs.Values[i])
+		}
+	}
+	return "", false
+}
+
+// varSpec 包级变量的声明、所在的 var 语句以及变量在声明中的下标
+func (si *Analyzer) varSpec(id *ast.Ident) (*ast.ValueSpec, *ast.GenDecl, int) {
+	if si.pkg == nil || si.pkg.TypesInfo == nil {
+		return nil, nil, 0
+	}
+	obj := si.pkg.TypesInfo.Uses[id]
+	if obj == nil {
+		return nil, nil, 0
+	}
+	for _, file := range si.pkg.Syntax {
+		for _, decl := range file.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if si.pkg.TypesInfo.Defs[name] == obj {
+						return vs, gd, i
+					}
+				}
+			}
+		}
+	}
+	return nil, nil, 0
+}
+
+// isAllowlistPattern 整个模式首尾锚定，并且只能匹配字母、数字与 _，^a|b$、^.*$、^[\w-]+$ 之类不算白名单
+func isAllowlistPattern(pattern string) bool {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	re = re.Simplify()
+	for re.Op == syntax.OpCapture {
+		re = re.Sub[0]
+	}
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 ||
+		re.Sub[0].Op != syntax.OpBeginText || re.Sub[len(re.Sub)-1].Op != syntax.OpEndText {
+		return false
+	}
+	return onlyWordChars(re)
+}
+
+// onlyWordChars 字符类与任意字符都不会匹配引号、空白与标点，字面量是固定的文本不做限制
+func onlyWordChars(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return false
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					return false
+				}
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		if !onlyWordChars(sub) {
+			return false
+		}
+	}
+	return true
+}
+
+// constArg 常量字符串实参
+func (si *Analyzer) constArg(e ast.Expr) (string, bool) {
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if tv, ok := si.pkg.TypesInfo.Types[e]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			return constant.StringVal(tv.Value), true
+		}
+	}
+	return constString(e)
+}
+
+func constString(e ast.Expr) (string, bool) {
+	if lit, ok := e.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+		if s, err := strconv.Unquote(lit.Value); err == nil {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// isMap 没有类型信息时认为是map
+func (si *Analyzer) isMap(e ast.Expr) bool {
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if t := si.pkg.TypesInfo.TypeOf(e); t != nil {
+			_, ok := t.Underlying().(*types.Map)
+			return ok
+		}
+	}
+	return true
+}
+
+// switchTagName switch x {...} 中的x
+func (si *Analyzer) switchTagName(sw *ast.SwitchStmt) string {
+	if sw.Tag == nil {
+		return ""
+	}
+	return exprName(sw.Tag)
+}
+
+// allConst case 的值都是常量
+func (si *Analyzer) allConst(list []ast.Expr) bool {
+	for _, e := range list {
+		if _, ok := e.(*ast.BasicLit); ok {
+			continue
+		}
+		if si.pkg == nil || si.pkg.TypesInfo == nil {
+			return false
+		}
+		if tv, ok := si.pkg.TypesInfo.Types[e]; !ok || tv.Value == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// exprName 变量或字段的名字，与 allPossibleInput 中的键一致
+func exprName(e ast.Expr) string {
+	switch e.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		en := NewExtraceName()
+		ast.Walk(en, e)
+		return en.result
+	}
+	return ""
+}
+
+// neverReturns panic、os.Exit、log.Fatal 等调用之后不会继续执行
+func neverReturns(call *ast.CallExpr) bool {
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		return fn.Name == "panic"
+	case *ast.SelectorExpr:
+		if x, ok := fn.X.(*ast.Ident); ok {
+			return (x.Name == "os" && fn.Sel.Name == "Exit") ||
+				(x.Name == "log" && (strings.HasPrefix(fn.Sel.Name, "Fatal") ||
+					strings.HasPrefix(fn.Sel.Name, "Panic")))
+		}
+	}
+	return false
+}
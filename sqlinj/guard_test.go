@@ -0,0 +1,32 @@
+package sqlinj
+
+import "testing"
+
+func TestIsAllowlistPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{`^[a-z_]+$`, true},
+		{`^\w+$`, true},
+		{`\A\d{1,10}\z`, true},
+		{`^(asc|desc)$`, true},
+		{`^(?i)(asc|desc)$`, true},
+		{`[a-z_]+`, false},
+		{`^[a-z_]+`, false},
+		{`^.*$`, false},
+		{`^.+$`, false},
+		{`^a|b$`, false},
+		{`^[\w-]+$`, false},
+		{`^[^']+$`, false},
+		{`^\S+$`, false},
+		{`^[a-z ]+$`, false},
+		{`(?m)^\w+$`, false},
+		{`^[a-z`, false},
+	}
+	for _, tt := range tests {
+		if got := isAllowlistPattern(tt.pattern); got != tt.want {
+			t.Errorf("isAllowlistPattern(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package sqlinj
+
+import "go/token"
+
+// isIdentifierContext 表名、列名等标识符的位置，无法使用占位符绑定
+func isIdentifierContext(context string) bool {
+	return context == ctxIdentifier || context == ctxQuotedIdentifier
+}
+
+// exceptIdentifiers 去掉只出现在标识符位置的参数，它们由 dynamic-identifier 规则单独报告；
+// keep 为真时该规则已关闭，只去掉字符串枚举类型的参数，其余的仍然作为 sql-injection 报告
+func exceptIdentifiers(injected []*Param, positions []token.Pos, slots []sqlSlot, keep bool) ([]*Param, []token.Pos) {
+	var r []*Param
+	var rp []token.Pos
+	for i, p := range injected {
+		ident, other := false, false
+		for _, slot := range slots {
+			if slot.para != p {
+				continue
+			}
+			if isIdentifierContext(slot.context) {
+				ident = true
+			} else {
+				other = true
+			}
+		}
+		if ident && !other && (!keep || p.enum) {
+			continue
+		}
+		r = append(r, p)
+		rp = append(rp, positions[i])
+	}
+	return r, rp
+}
+
+// identifierRule 被污染的参数直接拼接为表名、列名，如 ORDER BY %s、FROM t_%s。
+// 标识符不能使用占位符，需要白名单或者引用标识符的函数；字符串枚举类型与常量map中取出的值不报告
+type identifierRule struct{}
+
+func (identifierRule) ID() string {
+	return RuleIdentifier
+}
+
+func (identifierRule) Check(q *Query) []*Finding {
+	injected, _ := q.Input.injections(q.Params)
+	var r []*Finding
+	for _, slot := range q.Input.sqlSlots(q.Dialect) {
+		if !isIdentifierContext(slot.context) || slot.para == nil || slot.para.enum {
+			continue
+		}
+		for _, p := range injected {
+			if p != slot.para {
+				continue
+			}
+			c := contextRank[slot.context]
+			r = append(r, &Finding{
+				Message:     "dynamic identifier " + p.pName,
+				Pos:         q.Position(slot.pos),
+				Context:     slot.context,
+				Severity:    c.severity,
+				Remediation: c.remediation,
+			})
+			break
+		}
+	}
+	return r
+}
@@ -0,0 +1,129 @@
+package sqlinj
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// inventoryPart 语句中直接填入的动态部分
+type inventoryPart struct {
+	Name    string `json:"name"`
+	Context string `json:"context"`
+	Tainted bool   `json:"tainted"` // 来自函数参数且没有被净化
+}
+
+// inventoryEntry 一个数据库调用在一条路径上重建的语句
+type inventoryEntry struct {
+	Package   string          `json:"package"`
+	Func      string          `json:"func"`
+	File      string          `json:"file"`
+	Line      int             `json:"line"`
+	SinkType  string          `json:"sink_type"`
+	Method    string          `json:"method"`
+	Statement string          `json:"statement"` // 常量文本，动态部分为 {name}
+	Tainted   bool            `json:"tainted"`
+	Dynamic   []inventoryPart `json:"dynamic,omitempty"`
+}
+
+// recordInventory 记录数据库调用重建的语句，同一个调用上相同的语句只记录一次
+func (si *Analyzer) recordInventory(q *Query) {
+	injected, _ := q.Input.injections(q.Params)
+	e := &inventoryEntry{
+		Func:     q.Func,
+		SinkType: q.SinkType,
+		Method:   q.Method,
+	}
+	if si.pkg != nil {
+		e.Package = si.pkg.PkgPath
+	}
+	pos := q.Position(q.Call.Pos())
+	e.File, e.Line = pos.Filename, pos.Line
+
+	contexts := make(map[*Param]string)
+	for _, slot := range q.Input.sqlSlots(q.Dialect) {
+		contexts[slot.para] = slot.context
+	}
+	b := &strings.Builder{}
+	for _, p := range q.Input.sqlPieces(q.Dialect) {
+		if p.verb != "" {
+			// LIMIT %d 输出参数的名字，而不是词法分析使用的 0
+			name := p.verb
+			if p.value != nil && p.value.pName != "" {
+				name = p.value.pName
+			}
+			b.WriteString("{" + name + "}")
+			e.Dynamic = append(e.Dynamic, inventoryPart{Name: name, Context: ctxValue})
+			continue
+		}
+		if p.para == nil {
+			b.WriteString(p.text)
+			continue
+		}
+		b.WriteString("{" + p.para.pName + "}")
+		part := inventoryPart{Name: p.para.pName, Context: contexts[p.para]}
+		for _, ip := range injected {
+			if ip == p.para {
+				part.Tainted = true
+				e.Tainted = true
+			}
+		}
+		e.Dynamic = append(e.Dynamic, part)
+	}
+	e.Statement = b.String()
+
+	for _, old := range si.inventory {
+		if old.File == e.File && old.Line == e.Line && old.Statement == e.Statement {
+			return
+		}
+	}
+	si.inventory = append(si.inventory, e)
+}
+
+// WriteInventory 将所有语句写入文件，format 为 json 或 csv，为空时按文件的扩展名决定
+func (si *Analyzer) WriteInventory(file string, format string) error {
+	switch format {
+	case "":
+		format = "json"
+		if strings.HasSuffix(strings.ToLower(file), ".csv") {
+			format = "csv"
+		}
+	case "json", "csv":
+	default:
+		return fmt.Errorf("unknown inventory format %q, use json or csv", format)
+	}
+	var data []byte
+	if format == "csv" {
+		buf := &bytes.Buffer{}
+		w := csv.NewWriter(buf)
+		w.Write([]string{"package", "func", "file", "line", "sink_type", "method", "statement", "tainted", "dynamic"})
+		for _, e := range si.inventory {
+			parts := make([]string, 0, len(e.Dynamic))
+			for _, p := range e.Dynamic {
+				s := p.Name + ":" + p.Context
+				if p.Tainted {
+					s += ":tainted"
+				}
+				parts = append(parts, s)
+			}
+			w.Write([]string{e.Package, e.Func, e.File, strconv.Itoa(e.Line), e.SinkType, e.Method,
+				e.Statement, strconv.FormatBool(e.Tainted), strings.Join(parts, ";")})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	} else {
+		var err error
+		data, err = json.MarshalIndent(si.inventory, "", "  ")
+		if err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
@@ -0,0 +1,50 @@
+package sqlinj
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestInventory(t *testing.T) {
+	pkg, err := newFixtureLoader().load("fixture/inventory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := NewAnalyzer("")
+	si.Inventory = true
+	si.CheckPackages([]*packages.Package{pkg})
+	if len(si.inventory) != 1 {
+		t.Fatalf("got %d inventory entries, want 1", len(si.inventory))
+	}
+	e := si.inventory[0]
+	if want := "SELECT a FROM t WHERE b = {name} LIMIT {lim}"; e.Statement != want {
+		t.Errorf("statement = %q, want %q", e.Statement, want)
+	}
+	want := []inventoryPart{
+		{Name: "name", Context: ctxValue, Tainted: true},
+		{Name: "lim", Context: ctxValue},
+	}
+	if !reflect.DeepEqual(e.Dynamic, want) {
+		t.Errorf("dynamic = %+v, want %+v", e.Dynamic, want)
+	}
+
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		file   string
+		format string
+		ok     bool
+	}{
+		{"inv.json", "", true},
+		{"inv.csv", "", true},
+		{"inv.out", "csv", true},
+		{"inv.out", "yaml", false},
+	} {
+		err := si.WriteInventory(filepath.Join(dir, tt.file), tt.format)
+		if (err == nil) != tt.ok {
+			t.Errorf("WriteInventory(%q, %q) = %v", tt.file, tt.format, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// likeEscapeFuncs 转义 LIKE 通配符 % 与 _ 的函数，写法与 sanitizerFuncs 相同，-like-escapers 参数可以追加；
+// 名字中同时有 escape 与 like 或 wildcard 的函数也视为转义函数
+var likeEscapeFuncs = map[string]bool{}
+
+// AddLikeEscapers 追加逗号分隔的转义函数
+func AddLikeEscapers(list string) {
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			likeEscapeFuncs[name] = true
+		}
+	}
+}
+
+// isLikeEscapeCall 调用的是否为转义 LIKE 通配符的函数
+func (si *Analyzer) isLikeEscapeCall(call *ast.CallExpr) bool {
+	if len(call.Args) == 0 {
+		return false
+	}
+	for _, name := range si.callNames(call) {
+		if likeEscapeFuncs[name] {
+			return true
+		}
+	}
+	var name string
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		name = fn.Name
+	case *ast.SelectorExpr:
+		name = fn.Sel.Name
+	}
+	name = strings.ToLower(name)
+	return strings.Contains(name, "escape") &&
+		(strings.Contains(name, "like") || strings.Contains(name, "wildcard"))
+}
+
+// getDbInputFromLikeEscape 转义函数的结果与第一个实参相同，其中的参数标记为已转义
+func (si *Analyzer) getDbInputFromLikeEscape(call *ast.CallExpr) *DbInput {
+	arg := si.getDbInputFromRhs(call.Args[0])
+	if arg.isCollection() {
+		return arg
+	}
+	r := arg.deepclone()
+	for l := r; l != nil; l = l.follow {
+		paras := make([]*Param, len(l.paras))
+		for i, p := range l.paras {
+			if p != nil {
+				c := *p
+				c.escaped = true
+				paras[i] = &c
+			}
+		}
+		l.paras = paras
+	}
+	return r
+}
+
+// appendBinds 按顺序追加绑定参数，spread 为 args... 时展开集合的每个元素
+func appendBinds(binds []*DbInput, d *DbInput, spread bool) []*DbInput {
+	if !spread {
+		return append(binds, d)
+	}
+	if !d.isCollection() {
+		// 无法展开的参数，之后的绑定参数未知
+		return binds
+	}
+	for n := d.next; n != nil && n != d; n = n.next {
+		binds = append(binds, n)
+	}
+	return binds
+}
+
+// bindIndex 占位符对应的绑定参数的下标，? 按出现的顺序计数，$1、:1、@p1、?1 按编号，命名的占位符返回 -1
+func bindIndex(text string, d *Dialect, k *int) int {
+	if text == "?" {
+		if !d.questionMark() {
+			return -1
+		}
+		*k++
+		return *k - 1
+	}
+	if num := strings.TrimLeft(text[1:], "p"); isDigits(num) {
+		n, _ := strconv.Atoi(num)
+		return n - 1
+	}
+	return -1
+}
+
+// likeOperand 第i个记号是否为 LIKE 右侧的模式，包括 LIKE CONCAT('%', ?, '%') 与 LIKE '%' || ? || '%'
+func likeOperand(tokens []Token, i int) bool {
+	for j := i - 1; j >= 0; j-- {
+		t := tokens[j]
+		switch t.kind {
+		case TokKeyword:
+			return t.text == "LIKE" || t.text == "ILIKE"
+		case TokString, TokMarker, TokWord:
+			continue
+		case TokOperator:
+			if t.text == "|" {
+				continue
+			}
+		case TokPunct:
+			if t.text == "(" || t.text == "," {
+				continue
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// likeRule 来自函数参数的值绑定到 LIKE 的模式上时，用户可以输入 % 与 _ 匹配任意内容并导致全表扫描，
+// 值需要经过转义通配符的函数
+type likeRule struct{}
+
+func (likeRule) ID() string {
+	return RuleLikeWildcard
+}
+
+func (likeRule) Check(q *Query) []*Finding {
+	var r []*Finding
+	k := 0
+	for i, t := range q.Tokens {
+		if t.kind != TokMarker {
+			continue
+		}
+		n := bindIndex(t.text, q.Dialect, &k)
+		if n < 0 || n >= len(q.Binds) || !likeOperand(q.Tokens, i) {
+			continue
+		}
+		for _, p := range q.Binds[n].allParas() {
+			if p.sanitized || p.escaped || !taintedBy(p, q.Params) {
+				continue
+			}
+			r = append(r, &Finding{
+				Message:     "LIKE pattern from " + p.pName + " may contain wildcards",
+				Pos:         q.Position(t.pos),
+				Severity:    SeverityMedium,
+				Remediation: "escape % and _ in the value before binding it to LIKE",
+			})
+			break
+		}
+	}
+	return r
+}
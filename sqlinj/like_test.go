@@ -0,0 +1,74 @@
+package sqlinj
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLikeOperand(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool // 最后一个占位符是否为 LIKE 的模式
+	}{
+		{"SELECT a FROM t WHERE b LIKE ?", true},
+		{"SELECT a FROM t WHERE b ILIKE ?", true},
+		{"SELECT a FROM t WHERE b LIKE CONCAT('x', ?, 'y')", true},
+		{"SELECT a FROM t WHERE b LIKE 'x' || ? || 'y'", true},
+		{"SELECT a FROM t WHERE b = ?", false},
+		{"SELECT a FROM t WHERE b LIKE 'x' AND c = ?", false},
+		{"SELECT a FROM t WHERE b LIKE ? AND c = ?", false},
+	}
+	for _, tt := range tests {
+		tokens := scanSQL([]sqlPiece{{text: tt.sql}}, dialects["mysql"]).tokens
+		last := -1
+		for i, tk := range tokens {
+			if tk.kind == TokMarker {
+				last = i
+			}
+		}
+		if got := likeOperand(tokens, last); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestBindIndex(t *testing.T) {
+	tests := []struct {
+		markers []string
+		dialect string
+		want    []int
+	}{
+		{[]string{"?", "?", "?"}, "mysql", []int{0, 1, 2}},
+		{[]string{"$2", "$1", "$2"}, "postgres", []int{1, 0, 1}},
+		{[]string{"@p1", ":name"}, "sqlserver", []int{0, -1}},
+	}
+	for _, tt := range tests {
+		k := 0
+		var got []int
+		for _, m := range tt.markers {
+			got = append(got, bindIndex(m, dialects[tt.dialect], &k))
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%v in %s: got %v, want %v", tt.markers, tt.dialect, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// -like-escapers 指定的函数与名字中有 escape 和 like 的函数一样视为转义
+func TestLikeEscapers(t *testing.T) {
+	AddLikeEscapers("like.wild")
+	defer delete(likeEscapeFuncs, "like.wild")
+	got := checkFixture(t, "like")
+	want := []string{
+		"Bound like-wildcard 15",
+		"Index like-wildcard 25",
+		"Spread like-wildcard 31",
+		"Wrapped like-wildcard 20",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
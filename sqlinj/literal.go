@@ -0,0 +1,102 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"unicode/utf8"
+)
+
+// getDbInputFromLit 按Go的语义解码字面量，解码后的每个字节都记录它在源码中的位置；
+// 数字与字符字面量是常量，不会带来注入
+func getDbInputFromLit(lit *ast.BasicLit) *DbInput {
+	switch lit.Kind {
+	case token.STRING, token.CHAR:
+		s, positions := unquote(lit)
+		return &DbInput{
+			format:    s,
+			positions: positions,
+		}
+	}
+	// 数字常量，如 for i := 0; ...
+	return &DbInput{
+		format:    lit.Value,
+		positions: spanPositions(lit.Pos(), len(lit.Value)),
+	}
+}
+
+// unquote 解码字符串或字符字面量，无法解码时按原样去掉引号
+func unquote(lit *ast.BasicLit) (string, []token.Pos) {
+	v := lit.Value
+	if len(v) < 2 {
+		return v, nil
+	}
+	b := []byte{}
+	positions := []token.Pos{}
+	if v[0] == '`' {
+		// 原始字符串中的 \r 会被去掉
+		for i := 1; i < len(v)-1; i++ {
+			if v[i] != '\r' {
+				b = append(b, v[i])
+				positions = append(positions, lit.Pos()+token.Pos(i))
+			}
+		}
+		return string(b), positions
+	}
+	rest := v[1 : len(v)-1]
+	for len(rest) > 0 {
+		at := lit.Pos() + token.Pos(len(v)-1-len(rest))
+		r, multibyte, tail, err := strconv.UnquoteChar(rest, v[0])
+		if err != nil {
+			return v[1 : len(v)-1], nil
+		}
+		n := len(b)
+		if multibyte || lit.Kind == token.CHAR {
+			var buf [utf8.UTFMax]byte
+			b = append(b, buf[:utf8.EncodeRune(buf[:], r)]...)
+		} else {
+			// \x25 与 \045 是单个字节
+			b = append(b, byte(r))
+		}
+		for ; n < len(b); n++ {
+			positions = append(positions, at)
+		}
+		rest = tail
+	}
+	return string(b), positions
+}
+
+// spanPositions 从pos开始连续n个字节的位置
+func spanPositions(pos token.Pos, n int) []token.Pos {
+	if !pos.IsValid() {
+		return nil
+	}
+	r := make([]token.Pos, n)
+	for i := range r {
+		r[i] = pos + token.Pos(i)
+	}
+	return r
+}
+
+// hasPositions 每个字节的位置都已知
+func (di *DbInput) hasPositions() bool {
+	return len(di.positions) == len(di.format)
+}
+
+// posAt format 中第i个字节在源码中的位置
+func (di *DbInput) posAt(i int) token.Pos {
+	if !di.hasPositions() || i < 0 || i >= len(di.positions) {
+		return token.NoPos
+	}
+	return di.positions[i]
+}
+
+// joinPositions 两段 format 拼接后的位置，任何一段的位置未知时结果也未知
+func joinPositions(a []token.Pos, aOk bool, b []token.Pos, bOk bool) []token.Pos {
+	if !aOk || !bOk {
+		return nil
+	}
+	r := make([]token.Pos, 0, len(a)+len(b))
+	r = append(r, a...)
+	return append(r, b...)
+}
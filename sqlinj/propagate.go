@@ -0,0 +1,554 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxRepeat strings.Repeat 复制被污染片段的最大次数，多复制不会改变分析结果
+const maxRepeat = 3
+
+// getDbInputFromStdlib fmt 与 strings、strconv 中字符串函数的传播模型：常量文本按函数语义变换，被污染的片段保持污染
+func (si *Analyzer) getDbInputFromStdlib(call *ast.CallExpr, pkg string, fun string) (*DbInput, bool) {
+	args := call.Args
+	switch pkg + "." + fun {
+	case "fmt.Sprint":
+		return si.getDbInputFromSprint(args, false), true
+	case "fmt.Sprintln":
+		return si.getDbInputFromSprint(args, true), true
+	case "strings.ToUpper":
+		return si.mapArg(args, 0, strings.ToUpper), true
+	case "strings.ToLower":
+		return si.mapArg(args, 0, strings.ToLower), true
+	case "strings.Title":
+		return si.mapArg(args, 0, strings.Title), true
+	case "strings.ToTitle":
+		return si.mapArg(args, 0, strings.ToTitle), true
+	case "strings.TrimSpace":
+		if len(args) == 1 {
+			return si.getDbInputFromRhs(args[0]).trim(
+				func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) },
+				func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) }), true
+		}
+	case "strings.Trim", "strings.TrimLeft", "strings.TrimRight", "strings.TrimPrefix", "strings.TrimSuffix":
+		if len(args) != 2 {
+			break
+		}
+		cut, ok := si.constArg(args[1])
+		if !ok {
+			return si.getDbInputFromRhs(args[0]), true
+		}
+		var left, right func(string) string
+		switch fun {
+		case "Trim":
+			left = func(s string) string { return strings.TrimLeft(s, cut) }
+			right = func(s string) string { return strings.TrimRight(s, cut) }
+		case "TrimLeft":
+			left = func(s string) string { return strings.TrimLeft(s, cut) }
+		case "TrimRight":
+			right = func(s string) string { return strings.TrimRight(s, cut) }
+		case "TrimPrefix":
+			left = func(s string) string { return strings.TrimPrefix(s, cut) }
+		case "TrimSuffix":
+			right = func(s string) string { return strings.TrimSuffix(s, cut) }
+		}
+		return si.getDbInputFromRhs(args[0]).trim(left, right), true
+	case "strings.Replace", "strings.ReplaceAll":
+		if (fun == "Replace" && len(args) != 4) || (fun == "ReplaceAll" && len(args) != 3) {
+			break
+		}
+		n := -1
+		if fun == "Replace" {
+			if v, ok := si.constInt(args[3]); ok {
+				n = v
+			}
+		}
+		return si.getDbInputFromReplace(args[0], args[1], args[2], n), true
+	case "strings.Repeat":
+		if len(args) == 2 {
+			return si.getDbInputFromRepeat(args[0], args[1]), true
+		}
+	case "strings.Split", "strings.SplitN", "strings.Fields":
+		return si.getDbInputFromSplit(call, fun), true
+	case "strconv.Quote":
+		if len(args) == 1 {
+			return si.getDbInputFromQuote(args[0]), true
+		}
+	}
+	return nil, false
+}
+
+// getDbInputFromSprint fmt.Sprint 在相邻的两个操作数都不是字符串时加空格，fmt.Sprintln 总是加空格并以换行结束
+func (si *Analyzer) getDbInputFromSprint(args []ast.Expr, ln bool) *DbInput {
+	var r *DbInput
+	for i, arg := range args {
+		if i > 0 && (ln || (!si.isStringExpr(args[i-1]) && !si.isStringExpr(arg))) {
+			r = concatInput(r, &DbInput{format: " "})
+		}
+		r = concatInput(r, si.getDbInputFromRhs(arg))
+	}
+	if ln {
+		r = concatInput(r, &DbInput{format: "\n"})
+	}
+	if r == nil {
+		return &DbInput{}
+	}
+	return r
+}
+
+// concatInput 与 add 相同，但忽略空的一侧
+func concatInput(a *DbInput, b *DbInput) *DbInput {
+	if a == nil || (a.Empty() && a.follow == nil) {
+		return b
+	}
+	if b.Empty() && b.follow == nil {
+		return a
+	}
+	return a.add(b)
+}
+
+// isStringExpr 没有类型信息时认为是字符串
+func (si *Analyzer) isStringExpr(e ast.Expr) bool {
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if t := si.pkg.TypesInfo.TypeOf(e); t != nil {
+			b, ok := t.Underlying().(*types.Basic)
+			return ok && b.Info()&types.IsString != 0
+		}
+	}
+	return true
+}
+
+// constInt 常量整数实参
+func (si *Analyzer) constInt(e ast.Expr) (int, bool) {
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if tv, ok := si.pkg.TypesInfo.Types[e]; ok && tv.Value != nil {
+			if v, ok := constant.Int64Val(constant.ToInt(tv.Value)); ok {
+				return int(v), true
+			}
+		}
+	}
+	if lit, ok := e.(*ast.BasicLit); ok && lit.Kind == token.INT {
+		if v, err := strconv.Atoi(lit.Value); err == nil {
+			return v, true
+		}
+	}
+	if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.SUB {
+		if v, ok := si.constInt(u.X); ok {
+			return -v, true
+		}
+	}
+	return 0, false
+}
+
+func (si *Analyzer) mapArg(args []ast.Expr, i int, f func(string) string) *DbInput {
+	if i >= len(args) {
+		return &DbInput{}
+	}
+	return si.getDbInputFromRhs(args[i]).mapFormat(f)
+}
+
+// mapFormat 对每个片段的常量文本应用f，格式中的 %x 保持不变，参数不受影响
+func (di *DbInput) mapFormat(f func(string) string) *DbInput {
+	if di.isCollection() {
+		return di
+	}
+	r := di.deepclone()
+	for l := r; l != nil; l = l.follow {
+		l.format = mapText(l.format, f)
+	}
+	return r
+}
+
+// mapText 对 %x 之间的文本应用f
+func mapText(s string, f func(string) string) string {
+	result := ""
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+1 < len(s) {
+			result += f(s[start:i]) + s[i:i+2]
+			i++
+			start = i + 1
+		}
+	}
+	return result + f(s[start:])
+}
+
+// firstSlot 格式中第一个 %x 的位置，没有时返回 len(s)
+func firstSlot(s string) int {
+	if i := strings.Index(s, "%"); i >= 0 && i+1 < len(s) {
+		return i
+	}
+	return len(s)
+}
+
+// lastSlotEnd 格式中最后一个 %x 之后的位置，没有时返回 0
+func lastSlotEnd(s string) int {
+	if i := strings.LastIndex(s, "%"); i >= 0 && i+1 < len(s) {
+		return i + 2
+	}
+	return 0
+}
+
+// trim 从两端裁剪常量文本，遇到参数片段后停止，裁剪为空的常量片段被删除
+func (di *DbInput) trim(left func(string) string, right func(string) string) *DbInput {
+	if di.isCollection() {
+		return di
+	}
+	r := di.deepclone()
+	if left != nil {
+		for r != nil {
+			if len(r.paras) > 0 {
+				i := firstSlot(r.format)
+				r.format = left(r.format[:i]) + r.format[i:]
+				break
+			}
+			r.format = left(r.format)
+			if r.format != "" || r.follow == nil {
+				break
+			}
+			r = r.follow
+		}
+	}
+	if right != nil && r != nil {
+		var frags []*DbInput
+		for l := r; l != nil; l = l.follow {
+			frags = append(frags, l)
+		}
+		for i := len(frags) - 1; i >= 0; i-- {
+			l := frags[i]
+			if len(l.paras) > 0 {
+				j := lastSlotEnd(l.format)
+				l.format = l.format[:j] + right(l.format[j:])
+				break
+			}
+			l.format = right(l.format)
+			if l.format != "" || i == 0 {
+				break
+			}
+			frags[i-1].follow = nil
+		}
+	}
+	if r == nil {
+		return &DbInput{}
+	}
+	return r
+}
+
+// getDbInputFromReplace old为常量时在常量片段中用new的值替换old，new被污染时替换处同样被污染
+func (si *Analyzer) getDbInputFromReplace(s ast.Expr, old ast.Expr, new ast.Expr, n int) *DbInput {
+	di := si.getDbInputFromRhs(s)
+	w := si.getDbInputFromRhs(new)
+	o, ok := si.constArg(old)
+	if !ok || o == "" || di.isCollection() {
+		if _, ok := w.constText(); ok {
+			return di
+		}
+		return concatInput(di, w)
+	}
+	var r *DbInput
+	for l := di; l != nil; l = l.follow {
+		if len(l.paras) > 0 || n == 0 {
+			r = concatInput(r, l.clone())
+			continue
+		}
+		pieces := strings.Split(l.format, o)
+		if n > 0 && len(pieces) > n+1 {
+			pieces = append(pieces[:n], strings.Join(pieces[n:], o))
+		}
+		for k, piece := range pieces {
+			if k > 0 {
+				r = concatInput(r, w)
+				n--
+			}
+			if piece != "" {
+				r = concatInput(r, &DbInput{format: piece})
+			}
+		}
+	}
+	if r == nil {
+		return &DbInput{}
+	}
+	return r
+}
+
+// getDbInputFromRepeat 次数为常量时复制，常量文本完全复制，被污染的片段最多复制 maxRepeat 次
+func (si *Analyzer) getDbInputFromRepeat(s ast.Expr, count ast.Expr) *DbInput {
+	di := si.getDbInputFromRhs(s)
+	n, ok := si.constInt(count)
+	if !ok {
+		return di
+	}
+	if text, ok := di.constText(); ok {
+		return &DbInput{format: strings.Repeat(text, n)}
+	}
+	if n > maxRepeat {
+		n = maxRepeat
+	}
+	var r *DbInput
+	for i := 0; i < n; i++ {
+		r = concatInput(r, di)
+	}
+	if r == nil {
+		return &DbInput{}
+	}
+	return r
+}
+
+// constText follow链全部为常量时返回拼接后的文本
+func (di *DbInput) constText() (string, bool) {
+	if di.isCollection() {
+		return "", false
+	}
+	s := ""
+	for l := di; l != nil; l = l.follow {
+		if len(l.paras) > 0 || l.prepare != nil {
+			return "", false
+		}
+		s += l.format
+	}
+	return s, true
+}
+
+// newCollection 由元素组成的集合，与 []string{} 相同的表示
+func newCollection(elems []*DbInput) *DbInput {
+	di := &DbInput{}
+	(*di).next = di
+	for _, e := range elems {
+		di.appendTail(e)
+	}
+	return di
+}
+
+// getDbInputFromSplit 常量被拆分为常量元素的集合，被污染的字符串拆分后每个元素都被污染
+func (si *Analyzer) getDbInputFromSplit(call *ast.CallExpr, fun string) *DbInput {
+	if len(call.Args) == 0 {
+		return &DbInput{}
+	}
+	di := si.getDbInputFromRhs(call.Args[0])
+	if text, ok := di.constText(); ok {
+		var parts []string
+		switch fun {
+		case "Fields":
+			parts = strings.Fields(text)
+		case "Split":
+			if sep, ok := si.constArg(call.Args[1]); ok {
+				parts = strings.Split(text, sep)
+			}
+		case "SplitN":
+			if sep, ok := si.constArg(call.Args[1]); ok {
+				if n, ok := si.constInt(call.Args[2]); ok {
+					parts = strings.SplitN(text, sep, n)
+				}
+			}
+		}
+		if parts != nil {
+			elems := []*DbInput{}
+			for _, p := range parts {
+				elems = append(elems, &DbInput{format: p})
+			}
+			return newCollection(elems)
+		}
+	}
+	elem := &DbInput{format: "%s"}
+	for _, name := range di.paraNames() {
+		elem.paras = append(elem.paras, &Param{pName: name})
+	}
+	if len(elem.paras) == 0 {
+		elem.paras = []*Param{&Param{pName: "strings." + fun + "()", sanitized: true}}
+	}
+	return newCollection([]*DbInput{elem})
+}
+
+// getDbInputFromIndex 集合的下标访问，下标为常量时取对应元素，否则取第一个被污染的元素；常量map中取出的值是安全的
+func (si *Analyzer) getDbInputFromIndex(index *ast.IndexExpr) *DbInput {
+	if si.isConstMap(index.X) {
+		return safeParaInput(exprName(index.X) + "[]")
+	}
+	c := si.getDbInputFromRhs(index.X)
+	if !c.isCollection() {
+		// 参数等无法展开的切片或map，元素与来源相同
+		if !si.isSafeExpr(index) && c.follow == nil && len(c.paras) == 1 && c.paras[0] != nil {
+			return c
+		}
+		return nil
+	}
+	var elems []*DbInput
+	for n := c.next; n != nil && n != c; n = n.next {
+		elems = append(elems, n)
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+	if i, ok := si.constInt(index.Index); ok && i >= 0 && i < len(elems) {
+		return elems[i].deepclone()
+	}
+	for _, e := range elems {
+		// 元素仍然链在集合中，拷贝之后只计算它自己的污点
+		if d := e.deepclone(); taint(d) > 0 {
+			return d
+		}
+	}
+	name := exprName(index.X)
+	return safeParaInput(name + "[]")
+}
+
+// getDbInputFromQuote strconv.Quote 常量直接加引号，被污染的值加上双引号后仍然被污染
+func (si *Analyzer) getDbInputFromQuote(arg ast.Expr) *DbInput {
+	return quoteInput(si.getDbInputFromRhs(arg))
+}
+
+// quoteInput strconv.Quote 与 %q 的结果
+func quoteInput(di *DbInput) *DbInput {
+	if text, ok := di.constText(); ok {
+		return &DbInput{format: strconv.Quote(text)}
+	}
+	return concatInput(concatInput(&DbInput{format: `"`}, di), &DbInput{format: `"`})
+}
+
+// isStringSlice []string 或 []interface{} 类型
+func isStringSlice(t ast.Expr) bool {
+	Type, ok := t.(*ast.ArrayType)
+	if !ok {
+		return false
+	}
+	switch Elt := Type.Elt.(type) {
+	case *ast.Ident:
+		return Elt.Name == "string"
+	case *ast.InterfaceType:
+		return !Elt.Incomplete
+	}
+	return false
+}
+
+// isStringMap 值为 string 或 interface{} 的map
+func isStringMap(t ast.Expr) bool {
+	m, ok := t.(*ast.MapType)
+	return ok && isStringSlice(&ast.ArrayType{Elt: m.Value})
+}
+
+// assignElement marks[i] = v 向集合中加入一个可能的元素，元素已经存在时不变，循环因此能够收敛
+func (si *Analyzer) assignElement(index *ast.IndexExpr, v *DbInput) {
+	name := exprName(index.X)
+	c, ok := si.allPossibleInput[name]
+	if !ok || !c.isCollection() || v.Empty() || v.isCollection() {
+		return
+	}
+	key := v.toString()
+	for n := c.next; n != c; n = n.next {
+		if n.toString() == key {
+			return
+		}
+	}
+	si.allPossibleInput[name] = c.cloneCollection().appendTail(v.deepclone())
+}
+
+// getDbInputFromSlice s[low:high] 下标为常量或 len(s)-k 时截取常量文本，否则保留原值，截取不会消除污点
+func (si *Analyzer) getDbInputFromSlice(se *ast.SliceExpr) *DbInput {
+	di := si.getDbInputFromRhs(se.X)
+	text, ok := di.constText()
+	if !ok || se.Slice3 {
+		return di
+	}
+	low, high := 0, len(text)
+	if se.Low != nil {
+		if low, ok = si.sliceIndex(se.Low, se.X, len(text)); !ok {
+			return di
+		}
+	}
+	if se.High != nil {
+		if high, ok = si.sliceIndex(se.High, se.X, len(text)); !ok {
+			return di
+		}
+	}
+	if low < 0 || high > len(text) || low > high {
+		return di
+	}
+	return &DbInput{format: text[low:high]}
+}
+
+// sliceIndex 常量下标，或者 len(x)、len(x)-k 形式的下标，n 为 x 的长度
+func (si *Analyzer) sliceIndex(e ast.Expr, x ast.Expr, n int) (int, bool) {
+	if v, ok := si.constInt(e); ok {
+		return v, true
+	}
+	k := 0
+	if b, ok := e.(*ast.BinaryExpr); ok && b.Op == token.SUB {
+		v, ok := si.constInt(b.Y)
+		if !ok {
+			return 0, false
+		}
+		e, k = b.X, v
+	}
+	call, ok := e.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return 0, false
+	}
+	if fn, ok := call.Fun.(*ast.Ident); !ok || fn.Name != "len" {
+		return 0, false
+	}
+	if name := exprName(x); name == "" || exprName(call.Args[0]) != name {
+		return 0, false
+	}
+	return n - k, true
+}
+
+// conversionArg 转换为字符串类的类型时被转换的表达式：string(b)、[]byte(q)、[]rune(q)、SQL(q)
+func (si *Analyzer) conversionArg(call *ast.CallExpr) ast.Expr {
+	if len(call.Args) != 1 || call.Ellipsis.IsValid() {
+		return nil
+	}
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if tv, ok := si.pkg.TypesInfo.Types[call.Fun]; ok {
+			if tv.IsType() && isStringLike(tv.Type) {
+				return call.Args[0]
+			}
+			return nil
+		}
+	}
+	fun := call.Fun
+	if p, ok := fun.(*ast.ParenExpr); ok {
+		fun = p.X
+	}
+	switch t := fun.(type) {
+	case *ast.Ident:
+		if t.Name == "string" {
+			return call.Args[0]
+		}
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && (elt.Name == "byte" || elt.Name == "rune") {
+			return call.Args[0]
+		}
+	}
+	return nil
+}
+
+// isStringLike 底层类型为 string、[]byte 或 []rune
+func isStringLike(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Info()&types.IsString != 0
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok {
+			return b.Kind() == types.Byte || b.Kind() == types.Rune
+		}
+	}
+	return false
+}
+
+// isBytesExpr 表达式的类型为 []byte
+func (si *Analyzer) isBytesExpr(e ast.Expr) bool {
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if t := si.pkg.TypesInfo.TypeOf(e); t != nil {
+			if s, ok := t.Underlying().(*types.Slice); ok {
+				b, ok := s.Elem().Underlying().(*types.Basic)
+				return ok && b.Kind() == types.Byte
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Rule 检查数据库调用重建的sql的规则，其他包实现 Rule 之后在 init 中调用 RegisterRule 就可以增加自己的规则
+type Rule interface {
+	// ID 规则的ID，也是 Finding.Rule
+	ID() string
+	// Check 检查一条路径上到达数据库调用的sql，返回发现的问题
+	Check(q *Query) []*Finding
+}
+
+// Query 一条路径上到达数据库调用的sql
+type Query struct {
+	Input    *DbInput      // 参数填入之后的sql
+	Tokens   []Token       // sql的记号
+	Call     *ast.CallExpr // 数据库调用
+	SinkType string        // 数据库调用的类型，如 *sqlx.DB
+	Method   string        // 数据库调用的方法，如 Get
+	Func     string        // 数据库调用所在的函数
+	Params   []Param       // 所在函数的参数
+	Binds    []*DbInput    // 按顺序传入的绑定参数
+	Dialect  *Dialect
+	Fset     *token.FileSet
+}
+
+// Tainted 以%s方式拼接进sql并且没有被净化的函数参数
+func (q *Query) Tainted() []*Param {
+	r, _ := q.Input.injections(q.Params)
+	return r
+}
+
+// Verb 片段中一个使用参数的动词
+type Verb struct {
+	Verb  rune   // 动词，如 's'、'd'
+	Start int    // % 在片段格式中的位置
+	End   int    // 动词之后的位置
+	Param *Param // 填入的参数，常量时为 nil
+}
+
+// Fragments follow 链中的每个片段，按在sql中的顺序，拼接起来就是整个sql
+func (di *DbInput) Fragments() []*DbInput {
+	var r []*DbInput
+	for l := di; l != nil; l = l.follow {
+		r = append(r, l)
+	}
+	return r
+}
+
+// Format 片段的格式，参数填入的位置为 %s 之类的动词，%% 为 %
+func (di *DbInput) Format() string {
+	return di.format
+}
+
+// Verbs 片段中使用参数的动词以及填入的参数，%% 不算
+func (di *DbInput) Verbs() []Verb {
+	var r []Verb
+	for k, v := range verbs(di.format) {
+		verb := Verb{Verb: v.verb, Start: v.start, End: v.end}
+		if k < len(di.paras) {
+			verb.Param = di.paras[k]
+		}
+		r = append(r, verb)
+	}
+	return r
+}
+
+// Pos 片段格式中第i个字节在源码中的位置，未知时为 token.NoPos
+func (di *DbInput) Pos(i int) token.Pos {
+	return di.posAt(i)
+}
+
+// Position 源码中的位置，位置未知时使用数据库调用的位置
+func (q *Query) Position(pos token.Pos) token.Position {
+	if !pos.IsValid() {
+		pos = q.Call.Pos()
+	}
+	if q.Fset == nil {
+		return token.Position{}
+	}
+	return q.Fset.Position(pos)
+}
+
+type ruleEntry struct {
+	rule    Rule
+	enabled bool
+}
+
+// rules 注册的规则，按注册的顺序检查
+var rules []*ruleEntry
+
+// RegisterRule 注册默认启用的规则
+func RegisterRule(r Rule) {
+	rules = append(rules, &ruleEntry{rule: r, enabled: true})
+}
+
+// registerOptInRule 注册默认不启用的规则
+func registerOptInRule(r Rule) {
+	rules = append(rules, &ruleEntry{rule: r})
+}
+
+// ConfigureRules 逗号分隔的规则ID，以 - 开头时关闭规则，如 select-asterisk,-missing-where
+func ConfigureRules(list string) {
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		enabled := !strings.HasPrefix(id, "-")
+		id = strings.TrimPrefix(id, "-")
+		for _, e := range rules {
+			if e.rule.ID() == id {
+				e.enabled = enabled
+			}
+		}
+	}
+}
+
+// ruleEnabled 规则是否已注册并启用
+func ruleEnabled(id string) bool {
+	for _, e := range rules {
+		if e.rule.ID() == id {
+			return e.enabled
+		}
+	}
+	return false
+}
+
+func init() {
+	RegisterRule(injectionRule{})
+	RegisterRule(identifierRule{})
+	RegisterRule(missingWhereRule{})
+	RegisterRule(schemaRule{})
+	RegisterRule(likeRule{})
+	registerOptInRule(selectAsteriskRule{})
+}
+
+// query 一条路径上到达数据库调用的sql
+func (si *Analyzer) query(node *ast.CallExpr, iType string, fName string, di *DbInput) *Query {
+	q := &Query{
+		Input:    di,
+		Tokens:   di.sqlTokens(si.dialect),
+		Call:     node,
+		SinkType: iType,
+		Method:   fName,
+		Func:     si.curFunName,
+		Params:   si.parameters,
+		Binds:    di.binds,
+		Dialect:  si.dialect,
+	}
+	if si.pkg != nil {
+		q.Fset = si.pkg.Fset
+	}
+	return q
+}
+
+// checkRules 对一条路径上到达数据库调用的sql运行所有启用的规则
+func (si *Analyzer) checkRules(q *Query) {
+	for _, e := range rules {
+		if !e.enabled {
+			continue
+		}
+		for _, f := range e.rule.Check(q) {
+			if f.Rule == "" {
+				f.Rule = e.rule.ID()
+			}
+			if f.Func == "" {
+				f.Func = q.Func
+			}
+			si.report(q.Call.Pos(), f)
+		}
+	}
+}
+
+// injectionRule 以%s方式拼接进sql的函数参数
+type injectionRule struct{}
+
+func (injectionRule) ID() string {
+	return RuleSQLInjection
+}
+
+func (injectionRule) Check(q *Query) []*Finding {
+	if f := q.Input.reportError(q.Func, q.Params, q.Fset, q.Dialect); f != nil {
+		if !f.Pos.IsValid() {
+			// 经过辅助函数或摘要得到的参数没有位置，使用数据库调用的位置
+			f.Pos = q.Position(token.NoPos)
+		}
+		return []*Finding{f}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package sqlinj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+
+	"sqlinj/sqlinj"
+)
+
+// noUnion 外部包实现的规则，检查 UNION
+type noUnion struct{}
+
+func (noUnion) ID() string {
+	return "no-union"
+}
+
+func (noUnion) Check(q *sqlinj.Query) []*sqlinj.Finding {
+	for _, t := range q.Tokens {
+		if t.Kind() == sqlinj.TokKeyword && t.Text() == "UNION" {
+			return []*sqlinj.Finding{{
+				Message:  "UNION in query",
+				Pos:      q.Position(t.Pos()),
+				Severity: sqlinj.SeverityLow,
+			}}
+		}
+	}
+	return nil
+}
+
+func TestRegisterRule(t *testing.T) {
+	sqlinj.RegisterRule(noUnion{})
+	defer sqlinj.ConfigureRules("-no-union")
+
+	pkg, err := sqlinj.LoadFixture("union")
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := sqlinj.NewAnalyzer("")
+	si.CheckPackages([]*packages.Package{pkg})
+	var got []*sqlinj.Finding
+	for _, f := range si.Findings() {
+		if f.Rule == "no-union" {
+			got = append(got, f)
+		}
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d no-union findings, want 1: %v", len(got), got)
+	}
+	if f := got[0]; f.Func != "Union" || f.Pos.Line != 9 || f.Pos.Column != 37 {
+		t.Errorf("finding = %v, want Union at line 9 column 37", f)
+	}
+}
+
+// verbRule 外部包实现的规则，通过 Fragments 与 Verbs 读取填入sql的参数
+type verbRule struct {
+	got []string
+}
+
+func (*verbRule) ID() string {
+	return "verbs"
+}
+
+func (r *verbRule) Check(q *sqlinj.Query) []*sqlinj.Finding {
+	for _, f := range q.Input.Fragments() {
+		for _, v := range f.Verbs() {
+			if v.Param == nil {
+				continue
+			}
+			p := q.Position(f.Pos(v.Start))
+			r.got = append(r.got, fmt.Sprintf("%s %%%c %s %d:%d", q.Func, v.Verb, v.Param.Name(), p.Line, p.Column))
+		}
+	}
+	return nil
+}
+
+func TestQueryVerbs(t *testing.T) {
+	r := &verbRule{}
+	sqlinj.RegisterRule(r)
+	defer sqlinj.ConfigureRules("-verbs")
+
+	pkg, err := sqlinj.LoadFixture("mix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlinj.NewAnalyzer("").CheckPackages([]*packages.Package{pkg})
+	// 填入的参数在源码中的位置，%d 填入的参数已净化但保留名字
+	want := []string{"Mix %s col 11:74", "Lim %d lim 16:67"}
+	if strings.Join(r.got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("verbs:\n%s\nwant:\n%s", strings.Join(r.got, "\n"), strings.Join(want, "\n"))
+	}
+}
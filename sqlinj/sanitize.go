@@ -0,0 +1,253 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// sanitizerFuncs 结果可以安全拼接进sql的函数，可以是 包路径.函数名、包名.函数名 或 (*T).Method 形式，-sanitizers 参数可以追加
+var sanitizerFuncs = map[string]bool{
+	"strconv.Itoa":         true,
+	"strconv.FormatInt":    true,
+	"strconv.FormatUint":   true,
+	"strconv.FormatFloat":  true,
+	"strconv.FormatBool":   true,
+	"pq.QuoteIdentifier":   true,
+	"pq.QuoteLiteral":      true,
+	"pgx.Identifier":       true,
+	"sqlx.QuoteIdentifier": true,
+}
+
+// AddSanitizers 追加逗号分隔的净化函数
+func AddSanitizers(list string) {
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sanitizerFuncs[name] = true
+		}
+	}
+}
+
+// safeTypeNames 没有类型信息时，根据参数声明的类型判断，数值、布尔和时间类型无法注入
+var safeTypeNames = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+	"byte": true, "rune": true, "bool": true,
+	"time.Time": true, "time.Duration": true,
+}
+
+func isSafeTypeName(t string) bool {
+	return safeTypeNames[strings.TrimPrefix(t, "*")]
+}
+
+// isSafeType 数值、布尔和time包中的类型，包括以它们为底层类型的命名类型
+func isSafeType(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		if obj := n.Obj(); obj.Pkg() != nil && obj.Pkg().Path() == "time" {
+			return true
+		}
+	}
+	if b, ok := t.Underlying().(*types.Basic); ok {
+		return b.Info()&(types.IsNumeric|types.IsBoolean) != 0
+	}
+	return false
+}
+
+// isEnumType 以字符串为底层类型并且在包中声明了该类型常量的命名类型
+func isEnumType(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil {
+		return false
+	}
+	if b, ok := n.Underlying().(*types.Basic); !ok || b.Info()&types.IsString == 0 {
+		return false
+	}
+	scope := n.Obj().Pkg().Scope()
+	for _, name := range scope.Names() {
+		if c, ok := scope.Lookup(name).(*types.Const); ok && types.Identical(c.Type(), n) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnumExpr 表达式的类型是字符串枚举
+func (si *Analyzer) isEnumExpr(n ast.Node) bool {
+	e, ok := n.(ast.Expr)
+	if !ok || si.pkg == nil || si.pkg.TypesInfo == nil {
+		return false
+	}
+	if t := si.pkg.TypesInfo.TypeOf(e); t != nil {
+		return isEnumType(t)
+	}
+	return false
+}
+
+// isConstMap 由值全部为常量的map字面量初始化、包中没有再写入的包级变量，取出的值只能是这些常量之一
+func (si *Analyzer) isConstMap(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	vs, _, i := si.varSpec(id)
+	if vs == nil || i >= len(vs.Values) || !si.isMap(id) {
+		return false
+	}
+	lit, ok := vs.Values[i].(*ast.CompositeLit)
+	if !ok {
+		return false
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok || si.pkg.TypesInfo.Types[kv.Value].Value == nil {
+			return false
+		}
+	}
+	obj := si.pkg.TypesInfo.Uses[id]
+	written := false
+	for _, file := range si.pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			as, ok := n.(*ast.AssignStmt)
+			if !ok {
+				return !written
+			}
+			for _, lhs := range as.Lhs {
+				if index, ok := lhs.(*ast.IndexExpr); ok {
+					lhs = index.X
+				}
+				if x, ok := lhs.(*ast.Ident); ok && si.pkg.TypesInfo.Uses[x] == obj {
+					written = true
+				}
+			}
+			return !written
+		})
+	}
+	return !written
+}
+
+// isSafeExpr 表达式的类型无法造成注入
+func (si *Analyzer) isSafeExpr(n ast.Node) bool {
+	e, ok := n.(ast.Expr)
+	if !ok {
+		return false
+	}
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if t := si.pkg.TypesInfo.TypeOf(e); t != nil {
+			return isSafeType(t)
+		}
+	}
+	if id, ok := e.(*ast.Ident); ok {
+		for _, p := range si.parameters {
+			if p.pName == id.Name {
+				return isSafeTypeName(p.pType)
+			}
+		}
+	}
+	return false
+}
+
+// isTrustedField 字段或者它所在的上层字段带有 sqlinject:"trusted" 标签
+func (si *Analyzer) isTrustedField(n ast.Node) bool {
+	se, ok := n.(*ast.SelectorExpr)
+	if !ok || si.pkg == nil || si.pkg.TypesInfo == nil {
+		return false
+	}
+	if sel, ok := si.pkg.TypesInfo.Selections[se]; ok && sel.Kind() == types.FieldVal {
+		if reflect.StructTag(fieldTag(sel)).Get("sqlinject") == "trusted" {
+			return true
+		}
+	}
+	return si.isTrustedField(se.X)
+}
+
+// fieldTag 字段的标签，提升字段沿着嵌入路径找到声明它的结构体
+func fieldTag(sel *types.Selection) string {
+	t := sel.Recv()
+	tag := ""
+	for _, i := range sel.Index() {
+		if p, ok := t.Underlying().(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok || i >= st.NumFields() {
+			return ""
+		}
+		tag = st.Tag(i)
+		t = st.Field(i).Type()
+	}
+	return tag
+}
+
+// isSanitizerCall 调用的是否为净化函数
+func (si *Analyzer) isSanitizerCall(call *ast.CallExpr) bool {
+	for _, name := range si.callNames(call) {
+		if sanitizerFuncs[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// callNames 被调用函数的 包路径.函数名、包名.函数名 或 (*T).Method，以及源码中的 x.F
+func (si *Analyzer) callNames(call *ast.CallExpr) []string {
+	names := []string{}
+	if si.pkg != nil && si.pkg.TypesInfo != nil {
+		if fn := typeutil.StaticCallee(si.pkg.TypesInfo, call); fn != nil {
+			names = append(names, fn.FullName())
+			if fn.Pkg() != nil {
+				names = append(names, fn.Pkg().Name()+"."+fn.Name())
+			}
+		}
+	}
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		if x, ok := sel.X.(*ast.Ident); ok {
+			names = append(names, x.Name+"."+sel.Sel.Name)
+		}
+	}
+	return names
+}
+
+// getDbInputFromSanitizer 净化函数的结果：实参中的每个参数变成已净化的片段，没有参数时是一个已净化的值
+func (si *Analyzer) getDbInputFromSanitizer(call *ast.CallExpr) *DbInput {
+	var r *DbInput
+	for _, arg := range call.Args {
+		if _, ok := arg.(*ast.BasicLit); ok {
+			continue
+		}
+		for _, name := range si.getDbInputFromRhs(arg).paraNames() {
+			d := &DbInput{
+				format: "%s",
+				paras:  []*Param{&Param{pName: name, sanitized: true}},
+			}
+			if r == nil {
+				r = d
+			} else {
+				r.last().follow = d
+			}
+		}
+	}
+	if r == nil {
+		en := NewExtraceName()
+		ast.Walk(en, call.Fun)
+		r = &DbInput{
+			format: "%s",
+			paras:  []*Param{&Param{pName: en.result + "()", sanitized: true}},
+		}
+	}
+	return r
+}
+
+// safeParaInput 类型安全的变量
+func safeParaInput(name string) *DbInput {
+	return &DbInput{
+		format: "%s",
+		paras:  []*Param{&Param{pName: name, sanitized: true}},
+	}
+}
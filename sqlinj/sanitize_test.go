@@ -0,0 +1,18 @@
+package sqlinj
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddSanitizers(t *testing.T) {
+	AddSanitizers(" fixture/san.escape, ")
+	defer delete(sanitizerFuncs, "fixture/san.escape")
+	if sanitizerFuncs[""] {
+		t.Error("empty sanitizer name added")
+	}
+	got := checkFixture(t, "san")
+	if want := "Bad sql-injection 42"; strings.Join(got, "\n") != want {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), want)
+	}
+}
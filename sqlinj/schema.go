@@ -0,0 +1,603 @@
+package sqlinj
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sqlSchema 从建表与迁移脚本中得到的表结构，表名与列名都是小写
+type sqlSchema struct {
+	tables map[string]map[string]bool // 列未知时为 nil，如 CREATE TABLE t AS SELECT ...
+}
+
+// schema -schema 参数指定的表结构，未指定时不检查
+var schema *sqlSchema
+
+// LoadSchema 读取目录中的表结构，之后分析的sql按它检查表名与列名；出错时能够读取的部分仍然生效
+func LoadSchema(dir string) error {
+	s, err := loadSchema(dir)
+	schema = s
+	return err
+}
+
+// loadSchema 按文件名的顺序读取目录中所有的 .sql 文件，*.down.sql 是回滚脚本，不读取；
+// 某个文件读取失败时跳过它继续读取其他文件，返回读到的表结构以及第一个错误
+func loadSchema(dir string) (*sqlSchema, error) {
+	var files []string
+	walkErr := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".sql") && !strings.HasSuffix(path, ".down.sql") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	s := &sqlSchema{tables: make(map[string]map[string]bool)}
+	err := walkErr
+	for _, file := range files {
+		data, readErr := ioutil.ReadFile(file)
+		if readErr != nil {
+			if err == nil {
+				err = readErr
+			}
+			continue
+		}
+		s.apply(scanSQL([]sqlPiece{{text: string(data)}}, defaultDialect).tokens)
+	}
+	return s, err
+}
+
+// is 记号是给定的关键字或单词之一，不区分大小写
+func (t Token) is(words ...string) bool {
+	if t.kind != TokKeyword && t.kind != TokWord {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// isName 可以作为表名或列名的记号
+func (t Token) isName() bool {
+	return (t.kind == TokWord || t.kind == TokQuoted) && t.para == nil
+}
+
+// tokenAt 越界时返回空记号
+func tokenAt(tokens []Token, i int) Token {
+	if i < 0 || i >= len(tokens) {
+		return Token{}
+	}
+	return tokens[i]
+}
+
+// qualifiedName 从i开始的 a.b.c 形式的名字，返回最后一部分以及之后的位置
+func qualifiedName(tokens []Token, i int) (string, int, bool) {
+	if !tokenAt(tokens, i).isName() {
+		return "", i, false
+	}
+	name := tokens[i].text
+	i++
+	for tokenAt(tokens, i).text == "." && tokenAt(tokens, i+1).isName() {
+		name = tokens[i+1].text
+		i += 2
+	}
+	return strings.ToLower(name), i, true
+}
+
+// skipWords 跳过可选的单词，如 IF NOT EXISTS
+func skipWords(tokens []Token, i int, words ...string) int {
+	for k := 0; k < len(words); k++ {
+		if !tokenAt(tokens, i).is(words[k]) {
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// constraintWords 表定义中不是列的项
+var constraintWords = []string{"PRIMARY", "KEY", "INDEX", "UNIQUE", "CONSTRAINT", "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "EXCLUDE", "PERIOD"}
+
+// apply 执行脚本中的 CREATE TABLE、ALTER TABLE、DROP TABLE 与 RENAME TABLE
+func (s *sqlSchema) apply(tokens []Token) {
+	for _, stmt := range statements(tokens) {
+		switch {
+		case stmt[0].is("CREATE"):
+			s.create(stmt)
+		case stmt[0].is("ALTER"):
+			s.alter(stmt)
+		case stmt[0].is("DROP") && tokenAt(stmt, 1).is("TABLE"):
+			i := skipWords(stmt, 2, "IF", "EXISTS")
+			for {
+				name, next, ok := qualifiedName(stmt, i)
+				if !ok {
+					break
+				}
+				delete(s.tables, name)
+				if tokenAt(stmt, next).text != "," {
+					break
+				}
+				i = next + 1
+			}
+		case stmt[0].is("RENAME") && tokenAt(stmt, 1).is("TABLE"):
+			from, i, ok := qualifiedName(stmt, 2)
+			if to, _, ok2 := qualifiedName(stmt, i+1); ok && ok2 && tokenAt(stmt, i).is("TO") {
+				s.rename(from, to)
+			}
+		}
+	}
+}
+
+func (s *sqlSchema) rename(from, to string) {
+	if cols, ok := s.tables[from]; ok {
+		delete(s.tables, from)
+		s.tables[to] = cols
+	}
+}
+
+// create CREATE [TEMPORARY] TABLE [IF NOT EXISTS] name (列定义, 约束...)
+func (s *sqlSchema) create(stmt []Token) {
+	i := 1
+	for i < len(stmt) && !stmt[i].is("TABLE") {
+		if !stmt[i].is("TEMPORARY", "TEMP", "UNLOGGED", "GLOBAL", "LOCAL", "OR", "REPLACE") {
+			return
+		}
+		i++
+	}
+	j := skipWords(stmt, i+1, "IF", "NOT", "EXISTS")
+	ifNotExists := j == i+4
+	name, i, ok := qualifiedName(stmt, j)
+	if !ok {
+		return
+	}
+	if _, exists := s.tables[name]; exists && ifNotExists {
+		// 表已经存在时语句不执行
+		return
+	}
+	if tokenAt(stmt, i).text != "(" {
+		// CREATE TABLE t AS SELECT ...、CREATE TABLE t LIKE u
+		s.tables[name] = nil
+		return
+	}
+	cols := make(map[string]bool)
+	for _, item := range listItems(stmt, i) {
+		if col, ok := columnDef(item); ok {
+			cols[col] = true
+		}
+	}
+	s.tables[name] = cols
+}
+
+// listItems 从 ( 开始的括号中按 , 拆分的各项
+func listItems(tokens []Token, open int) [][]Token {
+	var r [][]Token
+	depth := tokens[open].depth + 1
+	start := open + 1
+	for i := open + 1; i < len(tokens); i++ {
+		t := tokens[i]
+		if t.depth < depth || (t.depth == depth-1 && t.text == ")") {
+			if i > start {
+				r = append(r, tokens[start:i])
+			}
+			break
+		}
+		if t.depth == depth && t.text == "," {
+			if i > start {
+				r = append(r, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return r
+}
+
+// columnDef 表定义中的一项是列定义时返回列名
+func columnDef(item []Token) (string, bool) {
+	if item[0].is(constraintWords...) || !item[0].isName() {
+		return "", false
+	}
+	return strings.ToLower(item[0].text), true
+}
+
+// alter ALTER TABLE [IF EXISTS] [ONLY] name 操作[, 操作]...
+func (s *sqlSchema) alter(stmt []Token) {
+	if !tokenAt(stmt, 1).is("TABLE") {
+		return
+	}
+	i := skipWords(stmt, 2, "IF", "EXISTS")
+	i = skipWords(stmt, i, "ONLY")
+	name, i, ok := qualifiedName(stmt, i)
+	if !ok {
+		return
+	}
+	cols, known := s.tables[name]
+	if !known || cols == nil {
+		return
+	}
+	start := i
+	for k := i; k <= len(stmt); k++ {
+		if k < len(stmt) && !(stmt[k].depth == 0 && stmt[k].text == ",") {
+			continue
+		}
+		if k > start {
+			name = s.alterAction(name, cols, stmt[start:k])
+		}
+		start = k + 1
+	}
+}
+
+// alterAction 执行一个 ALTER TABLE 的操作，返回改名之后的表名
+func (s *sqlSchema) alterAction(table string, cols map[string]bool, action []Token) string {
+	switch {
+	case action[0].is("ADD"):
+		i := skipWords(action, 1, "COLUMN")
+		i = skipWords(action, i, "IF", "NOT", "EXISTS")
+		if tokenAt(action, i).text == "(" {
+			// ADD (a INT, b INT)
+			for _, item := range listItems(action, i) {
+				if col, ok := columnDef(item); ok {
+					cols[col] = true
+				}
+			}
+		} else if i < len(action) {
+			if col, ok := columnDef(action[i:]); ok {
+				cols[col] = true
+			}
+		}
+	case action[0].is("DROP"):
+		i := skipWords(action, 1, "COLUMN")
+		i = skipWords(action, i, "IF", "EXISTS")
+		if i < len(action) {
+			if col, ok := columnDef(action[i:]); ok {
+				delete(cols, col)
+			}
+		}
+	case action[0].is("CHANGE"):
+		// CHANGE [COLUMN] old new 类型
+		i := skipWords(action, 1, "COLUMN")
+		if tokenAt(action, i).isName() && tokenAt(action, i+1).isName() {
+			delete(cols, strings.ToLower(action[i].text))
+			cols[strings.ToLower(action[i+1].text)] = true
+		}
+	case action[0].is("RENAME"):
+		i := 1
+		if tokenAt(action, i).is("COLUMN") || (tokenAt(action, i).isName() && tokenAt(action, i+1).is("TO")) {
+			// RENAME [COLUMN] a TO b
+			i = skipWords(action, i, "COLUMN")
+			if tokenAt(action, i).isName() && tokenAt(action, i+1).is("TO") && tokenAt(action, i+2).isName() {
+				delete(cols, strings.ToLower(action[i].text))
+				cols[strings.ToLower(action[i+2].text)] = true
+			}
+			return table
+		}
+		// RENAME [TO|AS] name
+		i = skipWords(action, i, "TO")
+		i = skipWords(action, i, "AS")
+		if to, _, ok := qualifiedName(action, i); ok {
+			s.rename(table, to)
+			return to
+		}
+	}
+	return table
+}
+
+// reservedWords 不在 sqlKeywords 中但也不是列名的单词
+var reservedWords = map[string]bool{
+	"NULL": true, "TRUE": true, "FALSE": true, "UNKNOWN": true, "DEFAULT": true, "INTERVAL": true,
+	"MICROSECOND": true, "SECOND": true, "MINUTE": true, "HOUR": true, "DAY": true, "WEEK": true,
+	"MONTH": true, "QUARTER": true, "YEAR": true, "CURRENT_TIMESTAMP": true, "CURRENT_DATE": true,
+	"CURRENT_TIME": true, "CURRENT_USER": true, "LOCALTIME": true, "LOCALTIMESTAMP": true,
+	"NULLS": true, "FIRST": true, "LAST": true, "DUPLICATE": true, "KEY": true, "IGNORE": true,
+	"REPLACE": true, "FOR": true, "SHARE": true, "NOWAIT": true, "SKIP": true, "LOCKED": true,
+	"RECURSIVE": true, "COLLATE": true, "END": true, "INTERSECT": true, "EXCEPT": true,
+	"NATURAL": true, "LATERAL": true, "IF": true, "CONFLICT": true, "DO": true, "NOTHING": true,
+	"EXCLUDED": true, "OVER": true, "PARTITION": true, "ROWS": true, "RANGE": true,
+	"PRECEDING": true, "FOLLOWING": true, "UNBOUNDED": true, "CURRENT": true, "ROW": true,
+	"FETCH": true, "NEXT": true, "ONLY": true, "TOP": true, "ANY": true, "SOME": true,
+	"REGEXP": true, "RLIKE": true, "SIMILAR": true, "TO": true, "DIV": true, "MOD": true,
+	"XOR": true, "BINARY": true, "STRAIGHT_JOIN": true, "LOCK": true, "MODE": true,
+	"AGAINST": true, "FILTER": true, "WITHIN": true, "LEADING": true, "TRAILING": true, "BOTH": true,
+}
+
+// isColumnWord 可能是列名的单词
+func (t Token) isColumnWord() bool {
+	if t.kind != TokWord || t.para != nil || t.text == "" {
+		return false
+	}
+	if c := t.text[0]; (c >= '0' && c <= '9') || c == '$' {
+		return false
+	}
+	return !reservedWords[strings.ToUpper(t.text)]
+}
+
+// tableRef FROM、JOIN、UPDATE 中引用的表，cols 为 nil 时列未知，如子查询、CTE 或者不在表结构中的表
+type tableRef struct {
+	name  string
+	alias string
+	cols  map[string]bool
+}
+
+// sqlScope 一个 SELECT、UPDATE 或 DELETE 中可以引用的表，子查询可以引用外层的表
+type sqlScope struct {
+	parent   *sqlScope
+	tables   []*tableRef
+	aliases  map[string]bool // 选择列表中定义的别名
+	complete bool            // 没有直接填入的参数，所有的表都已知
+}
+
+// lookup 按别名或表名查找表
+func (s *sqlScope) lookup(name string) *tableRef {
+	for ; s != nil; s = s.parent {
+		for _, t := range s.tables {
+			if t.alias == name || t.name == name {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// schemaChecker 对一条语句检查表结构
+type schemaChecker struct {
+	q        *Query
+	tokens   []Token
+	ctes     map[string]bool
+	findings []*Finding
+}
+
+func (sc *schemaChecker) add(t Token, msg string) {
+	sc.findings = append(sc.findings, &Finding{
+		Message:     msg,
+		Pos:         sc.q.Position(t.pos),
+		Severity:    SeverityMedium,
+		Remediation: "check the query against the schema or update the schema files",
+	})
+}
+
+// closeParen 与i处的 ( 对应的 ) 的位置
+func closeParen(tokens []Token, i int) int {
+	for j := i + 1; j < len(tokens); j++ {
+		if tokens[j].depth == tokens[i].depth && tokens[j].text == ")" {
+			return j
+		}
+	}
+	return len(tokens)
+}
+
+// isSubquery i处的 ( 开始一个子查询
+func isSubquery(tokens []Token, i int) bool {
+	return tokens[i].kind == TokPunct && tokens[i].text == "(" && tokenAt(tokens, i+1).is("SELECT", "WITH")
+}
+
+// inCall i 处的记号在函数调用的括号中，如 EXTRACT(YEAR FROM d)、TRIM(LEADING 'a' FROM y) 中的 FROM
+func inCall(tokens []Token, lo, i int) bool {
+	depth := tokens[i].depth
+	for j := i - 1; j >= lo && depth > tokens[lo].depth; j-- {
+		if tokens[j].kind != TokPunct || tokens[j].text != "(" || tokens[j].depth != depth-1 {
+			continue
+		}
+		if tokenAt(tokens, j-1).kind == TokWord {
+			return true
+		}
+		depth--
+	}
+	return false
+}
+
+// checkStatement 检查一条语句，CTE 的名字对应的表的列未知
+func (sc *schemaChecker) checkStatement() {
+	sc.ctes = make(map[string]bool)
+	for i, t := range sc.tokens {
+		if t.isName() && tokenAt(sc.tokens, i+1).is("AS") && isSubquery(sc.tokens, i+2) {
+			sc.ctes[strings.ToLower(t.text)] = true
+		}
+	}
+	sc.checkRange(0, len(sc.tokens), nil)
+}
+
+// checkRange 检查 [lo, hi) 中的查询，UNION 的每一部分单独检查，子查询递归检查
+func (sc *schemaChecker) checkRange(lo, hi int, parent *sqlScope) {
+	if lo >= hi {
+		return
+	}
+	depth := sc.tokens[lo].depth
+	start := lo
+	for i := lo; i <= hi; i++ {
+		if i < hi && !(sc.tokens[i].depth == depth && sc.tokens[i].is("UNION", "INTERSECT", "EXCEPT")) {
+			continue
+		}
+		sc.checkPart(start, i, parent)
+		start = i + 1
+	}
+}
+
+// checkPart 检查一个 SELECT、INSERT、UPDATE 或 DELETE
+func (sc *schemaChecker) checkPart(lo, hi int, parent *sqlScope) {
+	tokens := sc.tokens
+	scope := &sqlScope{parent: parent, aliases: make(map[string]bool), complete: true}
+	defs := make(map[int]bool)
+	var nested [][2]int
+	for i := lo; i < hi; i++ {
+		if isSubquery(tokens, i) {
+			end := closeParen(tokens, i)
+			nested = append(nested, [2]int{i + 1, end})
+			i = end
+			continue
+		}
+		t := tokens[i]
+		if t.kind == TokParam || (t.para != nil && t.kind != TokString) {
+			scope.complete = false
+		}
+		if !t.is("FROM", "JOIN", "UPDATE", "INTO") || (t.is("UPDATE") && tokenAt(tokens, i-1).is("KEY")) ||
+			inCall(tokens, lo, i) {
+			continue
+		}
+		i = sc.tableRefs(t, i+1, hi, scope, defs)
+	}
+	for _, n := range nested {
+		sc.checkRange(n[0], n[1], scope)
+	}
+
+	var unqualified []Token
+	inNested := func(i int) bool {
+		for _, n := range nested {
+			if i >= n[0]-1 && i <= n[1] {
+				return true
+			}
+		}
+		return false
+	}
+	for i := lo; i < hi; i++ {
+		t := tokens[i]
+		if defs[i] || inNested(i) || !t.isColumnWord() {
+			continue
+		}
+		prev, next := tokenAt(tokens, i-1), tokenAt(tokens, i+1)
+		switch {
+		case next.text == "(" || prev.text == ":" || prev.text == "." || (next.is("AS") && isSubquery(tokens, i+2)):
+			// 函数、类型转换、已经处理的限定名或者 CTE 的名字
+		case prev.is("AS"):
+			scope.aliases[strings.ToLower(t.text)] = true
+		case next.text == ".":
+			col := tokenAt(tokens, i+2)
+			ref := scope.lookup(strings.ToLower(t.text))
+			if ref != nil && ref.cols != nil && col.isName() && !ref.cols[strings.ToLower(col.text)] {
+				sc.add(col, "unknown column "+t.text+"."+col.text)
+			}
+		case prev.isOperand() && prev.kind != TokMarker && prev.depth == t.depth &&
+			(next.text == "," || next.is("FROM") || next.kind == TokNone):
+			// SELECT a b 中的别名 b
+			scope.aliases[strings.ToLower(t.text)] = true
+		default:
+			unqualified = append(unqualified, t)
+		}
+	}
+	for _, t := range unqualified {
+		sc.checkColumn(scope, t)
+	}
+}
+
+// tableRefs 解析 FROM、JOIN、UPDATE、INTO 之后引用的表，返回最后处理的位置
+func (sc *schemaChecker) tableRefs(kw Token, i, hi int, scope *sqlScope, defs map[int]bool) int {
+	tokens := sc.tokens
+	for i < hi {
+		start := i
+		ref := &tableRef{}
+		if isSubquery(tokens, i) {
+			// 子查询，之后处理
+			return i - 1
+		}
+		name, next, ok := qualifiedName(tokens, i)
+		if !ok {
+			if tokenAt(tokens, i).kind == TokParam || tokenAt(tokens, i).para != nil {
+				scope.complete = false
+			}
+			return i - 1
+		}
+		ref.name, ref.alias = name, name
+		if cols, found := schema.tables[name]; found {
+			ref.cols = cols
+		} else if !sc.ctes[name] {
+			sc.add(tokens[i], "unknown table "+name)
+		}
+		i = next
+		if tokenAt(tokens, i).is("AS") {
+			i++
+		}
+		if a := tokenAt(tokens, i); a.isName() && a.kind != TokKeyword && !reservedWords[strings.ToUpper(a.text)] && i < hi {
+			ref.alias = strings.ToLower(a.text)
+			i++
+		}
+		for k := start; k < i; k++ {
+			defs[k] = true
+		}
+		if kw.is("INTO") {
+			sc.insertColumns(ref, i, hi, defs)
+			return i - 1
+		}
+		scope.tables = append(scope.tables, ref)
+		if !kw.is("FROM") || tokenAt(tokens, i).text != "," {
+			return i - 1
+		}
+		i++
+	}
+	return i - 1
+}
+
+// insertColumns INSERT INTO t (a, b) 中的列必须在表中
+func (sc *schemaChecker) insertColumns(ref *tableRef, i, hi int, defs map[int]bool) {
+	tokens := sc.tokens
+	if i >= hi || tokens[i].text != "(" || isSubquery(tokens, i) {
+		return
+	}
+	end := closeParen(tokens, i)
+	for k := i; k <= end && k < hi; k++ {
+		defs[k] = true
+	}
+	if ref.cols == nil {
+		return
+	}
+	for _, item := range listItems(tokens, i) {
+		if len(item) == 1 && item[0].isName() && !ref.cols[strings.ToLower(item[0].text)] {
+			sc.add(item[0], "insert into unknown column "+ref.name+"."+item[0].text)
+		}
+	}
+}
+
+// checkColumn 没有限定的列在所在的查询中没有或者有多个表包含时报告，找不到时再查找外层的查询
+func (sc *schemaChecker) checkColumn(scope *sqlScope, t Token) {
+	col := strings.ToLower(t.text)
+	if len(scope.tables) == 0 {
+		return
+	}
+	for s := scope; s != nil; s = s.parent {
+		if !s.complete || s.aliases[col] {
+			return
+		}
+		var found []string
+		for _, ref := range s.tables {
+			if ref.cols == nil {
+				return
+			}
+			if ref.cols[col] {
+				found = append(found, ref.alias)
+			}
+		}
+		if len(found) > 1 {
+			sc.add(t, "ambiguous column "+t.text+" in "+strings.Join(found, ", "))
+			return
+		}
+		if len(found) == 1 {
+			return
+		}
+	}
+	sc.add(t, "unknown column "+t.text)
+}
+
+// schemaRule 按 -schema 指定的表结构检查表名与列名
+type schemaRule struct{}
+
+func (schemaRule) ID() string {
+	return RuleSchema
+}
+
+func (schemaRule) Check(q *Query) []*Finding {
+	if schema == nil {
+		return nil
+	}
+	var r []*Finding
+	for _, stmt := range statements(q.Tokens) {
+		sc := &schemaChecker{q: q, tokens: stmt}
+		sc.checkStatement()
+		r = append(r, sc.findings...)
+	}
+	return r
+}
@@ -0,0 +1,89 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSchemaApply(t *testing.T) {
+	tests := []struct {
+		script string
+		table  string
+		want   string // 逗号分隔的列，按名字排序
+	}{
+		{"CREATE TABLE t (a INT, b TEXT, PRIMARY KEY (a))", "t", "a,b"},
+		{"CREATE TABLE t (a INT); ALTER TABLE t ADD COLUMN c INT", "t", "a,c"},
+		{"CREATE TABLE t (a INT); ALTER TABLE t DROP COLUMN a", "t", ""},
+		// 表已经存在时 IF NOT EXISTS 的语句不执行
+		{"CREATE TABLE t (a INT, b INT); CREATE TABLE IF NOT EXISTS t (a INT)", "t", "a,b"},
+		{"CREATE TABLE IF NOT EXISTS t (a INT)", "t", "a"},
+		{"CREATE TABLE t (a INT); DROP TABLE t; CREATE TABLE IF NOT EXISTS t (c INT)", "t", "c"},
+	}
+	for _, tt := range tests {
+		s := &sqlSchema{tables: make(map[string]map[string]bool)}
+		s.apply(scanSQL([]sqlPiece{{text: tt.script}}, dialects["mysql"]).tokens)
+		var cols []string
+		for c := range s.tables[tt.table] {
+			cols = append(cols, c)
+		}
+		sort.Strings(cols)
+		if got := strings.Join(cols, ","); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	s := &sqlSchema{tables: make(map[string]map[string]bool)}
+	s.apply(scanSQL([]sqlPiece{{text: "CREATE TABLE t (a INT, d DATE, x TEXT, y TEXT)"}}, dialects["mysql"]).tokens)
+	old := schema
+	schema = s
+	defer func() { schema = old }()
+	tests := []struct {
+		sql  string
+		want string // 逗号分隔的报告
+	}{
+		{"SELECT a FROM t", ""},
+		{"SELECT a FROM u", "unknown table u"},
+		{"SELECT b FROM t", "unknown column b"},
+		// 函数调用括号中的 FROM 不是表
+		{"SELECT EXTRACT(YEAR FROM d) FROM t", ""},
+		{"SELECT SUBSTRING(x FROM 2) FROM t", ""},
+		{"SELECT TRIM(LEADING 'a' FROM y) FROM t", ""},
+		{"SELECT TRIM(LEADING 'a' FROM z) FROM t", "unknown column z"},
+		{"SELECT a FROM (SELECT a FROM u) s", "unknown table u"},
+	}
+	for _, tt := range tests {
+		q := &Query{Call: &ast.CallExpr{Fun: ast.NewIdent("Query")}, Tokens: scanSQL([]sqlPiece{{text: tt.sql}}, dialects["mysql"]).tokens}
+		var got []string
+		for _, f := range (schemaRule{}).Check(q) {
+			got = append(got, f.Message)
+		}
+		if g := strings.Join(got, ","); g != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.sql, g, tt.want)
+		}
+	}
+}
+
+func TestLoadSchemaPartial(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "1.sql"), []byte("CREATE TABLE t (a INT)"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 指向不存在的文件，读取失败
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "2.sql")); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadSchema(dir)
+	if err == nil {
+		t.Error("want an error for the unreadable file")
+	}
+	if s == nil || !s.tables["t"]["a"] {
+		t.Errorf("want the readable part of the schema, got %v", s)
+	}
+}
@@ -0,0 +1,1808 @@
+package sqlinj
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/packages"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// getPackagePaths get path contain package from root path
+func getPackagePaths(root string) ([]string, error) {
+	if strings.HasSuffix(root, "...") {
+		root = root[0 : len(root)-3]
+	} else {
+		return []string{root}, nil
+	}
+
+	paths := map[string]bool{}
+	err := filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+		if filepath.Ext(path) == ".go" {
+			path = filepath.Dir(path)
+
+			paths[path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return []string{}, err
+	}
+
+	result := []string{}
+	for path := range paths {
+		result = append(result, path)
+	}
+	return result, nil
+}
+
+// Param 拼接进sql的值的来源，通常是函数参数或者它的字段
+type Param struct {
+	pName      string
+	pType      string
+	conflation []*Param
+	sanitized  bool
+	enum       bool // 值的类型是字符串枚举，只能是声明的常量之一
+	escaped    bool // 经过转义 LIKE 通配符的函数
+}
+
+func (fp *Param) String() string {
+	s := fp.pName
+	for _, p := range fp.conflation {
+		s = s + "#" + (*p).String()
+	}
+	if s == "" {
+		s = "∅"
+	}
+	if fp.sanitized {
+		s = s + "(safe)"
+	}
+	if fp.enum {
+		s = s + "(enum)"
+	}
+	if fp.escaped {
+		s = s + "(escaped)"
+	}
+	return s
+}
+
+// Name 参数的名字，字段为 p.Field 形式
+func (fp *Param) Name() string {
+	return fp.pName
+}
+
+// Type 函数参数声明的类型，不是函数参数时为空
+func (fp *Param) Type() string {
+	return fp.pType
+}
+
+// Sanitized 是否经过净化函数或者类型安全
+func (fp *Param) Sanitized() bool {
+	return fp.sanitized
+}
+
+func (fp *Param) conflate(v *Param) {
+	fp.conflation = append(fp.conflation, v)
+}
+
+const (
+	StateMentAnalysisSTART int = iota
+	StateMentAnalysisFUNCTION
+	StateMentAnalysisFUNCTIONBODY
+)
+
+type DbInput struct {
+	format    string
+	paras     []*Param
+	next      *DbInput
+	follow    *DbInput
+	prepare   *DbInput
+	positions []token.Pos // format 中每个字节在源码中的位置，未知时长度与 format 不同
+	binds     []*DbInput  // 数据库调用的绑定参数，args... 展开为每个元素
+}
+
+func (di *DbInput) clone() *DbInput {
+	r := &DbInput{}
+	*r = *di
+	r.next = nil
+	r.follow = nil
+	r.prepare = nil
+
+	return r
+}
+
+func (di *DbInput) deepclone() *DbInput {
+	r := di.clone()
+	rloop := r
+	dfollow := di.follow
+	for ; dfollow != nil; dfollow = dfollow.follow {
+		rloop.follow = dfollow.clone()
+		rloop = rloop.follow
+	}
+	return r
+}
+
+func (di *DbInput) toStringSingle() string {
+	s := ""
+	if di.format == "" {
+		s = "(blank)"
+	} else {
+		s = fmt.Sprintf("%d:", len(di.format))
+		s += di.format
+	}
+
+	s = s + "" + "["
+	for _, p := range di.paras {
+		s = s + (*p).String() + ","
+	}
+	s = s + "]" //+ fmt.Sprintf("%p", di)
+	return s
+}
+
+func (di *DbInput) toString() string {
+	s := di.toStringSingle()
+	for f := di.follow; f != nil; f = f.follow {
+		s = s + "-->" + f.toStringSingle()
+	}
+	return s
+}
+
+func (di *DbInput) String() string {
+	dump := di
+	s := (*dump).toString()
+	for {
+		if (*dump).next == di || (*dump).next == nil {
+			break
+		}
+		dump = (*dump).next
+		s = s + "==>" + (*dump).toString()
+	}
+	return s
+}
+
+func (di *DbInput) updateForAdd() {
+	if di.Empty() {
+		panic(1)
+	}
+	if len(di.paras) > 0 && ((*di).format == "") {
+		s := "%s"
+		di.format = s
+	}
+}
+
+func (di *DbInput) Empty() bool {
+	return di.format == "" && len(di.paras) == 0 && di.next == nil
+}
+
+func (di *DbInput) isCollection() bool {
+	return di.next != nil
+}
+
+func (di *DbInput) appendTail(v *DbInput) *DbInput {
+	// get tail
+	if (*di).next == nil {
+		panic(1)
+	}
+	tail := (*di).next
+	for {
+		if (*tail).next == di {
+			break
+		}
+		tail = (*tail).next
+	}
+	(*tail).next = v
+	(*v).next = di
+	return di
+}
+
+// cloneCollection 复制集合，append 等操作会修改集合，其他路径上的同一个集合不能受影响
+func (di *DbInput) cloneCollection() *DbInput {
+	r := di.clone()
+	tail := r
+	for n := di.next; n != nil && n != di; n = n.next {
+		tail.next = n.deepclone()
+		tail = tail.next
+	}
+	tail.next = r
+	return r
+}
+
+func (di *DbInput) likeStringJoin(v *DbInput) *DbInput {
+	// deep copy
+	if (*di).next == nil {
+		panic(1)
+	}
+	n1 := (*di).next
+	n2 := (*n1).next
+	for {
+		if n1 == n2 || n2 == di {
+			break
+		}
+		newV := &DbInput{
+			format:    (*v).format,
+			paras:     (*v).paras,
+			positions: (*v).positions,
+		}
+		(*n1).next = newV
+		(*newV).next = n2
+		n1 = n2
+		n2 = (*n1).next
+	}
+	return di
+}
+
+func (di *DbInput) filterEmptyPara() {
+	c := di.getParaCountFromFormat()
+	for i := len(di.paras); i < c; i++ {
+		di.paras = append(di.paras, nil)
+	}
+}
+
+// getParaCountFromFormat 格式中使用参数的动词个数，%% 不算
+func (di *DbInput) getParaCountFromFormat() int {
+	return len(verbs(di.format))
+}
+
+// getFormatPos index from 0, 第index个使用参数的动词，返回动词字母的位置
+func (di *DbInput) getFormatPos(index int) (int, rune, bool) {
+	vs := verbs(di.format)
+	if index < 0 || index >= len(vs) {
+		return 0, 0, false
+	}
+	return vs[index].end - 1, vs[index].verb, true
+}
+
+// getFormatOrQuestionMarkPos both find %x and ?, %% 与动词内部的字符不算，引号与注释中的 ? 不算
+func (di *DbInput) getFormatOrQuestionMarkPos(index int) (int, rune, bool) {
+	ss := (&sqlLexer{}).slotsIn(di.format, nil)
+	if index < 0 || index >= len(ss) {
+		return 0, 0, false
+	}
+	return ss[index].end - 1, ss[index].verb, true
+}
+
+func (di *DbInput) appendParas(paras []*Param) {
+	for _, p1 := range paras {
+		found := false
+		for j, p2 := range di.paras {
+			if p2 == nil {
+				di.paras[j] = p1
+				found = true
+				break
+			}
+		}
+		if !found {
+			di.paras = append(di.paras, p1)
+		}
+	}
+}
+
+func (di *DbInput) last() *DbInput {
+	for ; ; di = di.follow {
+		if di.follow == nil {
+			return di
+		}
+	}
+}
+
+func (di *DbInput) add(input *DbInput) *DbInput {
+	r1 := di.deepclone()
+	r2 := input.deepclone()
+	r1.last().follow = r2
+	return r1
+}
+
+func (di *DbInput) concat(input *DbInput) *DbInput {
+	(*di).positions = joinPositions(di.positions, di.hasPositions(), input.positions, input.hasPositions())
+	(*di).format += input.format
+	di.paras = append(di.paras, input.paras...)
+	// merge paras
+	return di
+}
+
+func (di *DbInput) merge() *DbInput {
+	r := &DbInput{}
+	n := (*di).next
+	for {
+		if n == nil || n == di {
+			break
+		}
+		// 元素本身可能是follow链
+		for l := n; l != nil; l = l.follow {
+			r = (*r).concat(l)
+		}
+		n = (*n).next
+	}
+	return r
+}
+
+func (di *DbInput) addFormat(input *DbInput) *DbInput {
+	return input.mergePureFormat().deepSplit()
+}
+
+func (di *DbInput) addFormatDb(input *DbInput, d *Dialect) *DbInput {
+	return input.mergePureFormat().deepSplitDB(d)
+}
+
+func (di *DbInput) mergePureFormat() *DbInput {
+	r := di.deepclone()
+	for l := r; l.follow != nil; {
+		follow := l.follow.follow
+		if len(l.paras) == 0 && len(l.follow.paras) == 0 {
+			l.positions = joinPositions(l.positions, l.hasPositions(), l.follow.positions, l.follow.hasPositions())
+			l.format = l.format + l.follow.format
+			l.follow = follow
+		} else {
+			l = l.follow
+		}
+	}
+	return r
+}
+
+func (di *DbInput) deepSplit() *DbInput {
+	r := di.split()
+	l := di
+	for l.follow != nil {
+		r.last().follow = l.follow.split()
+		l = l.follow
+	}
+	return r
+}
+
+func (di *DbInput) split() *DbInput {
+	r := di.clone()
+	if len(r.paras) > 0 {
+		return r
+	}
+	l := r
+	for {
+		if i, _, ok := l.getFormatPos(0); ok {
+			f := l.clone()
+			lf := l.format[:i+1]
+			if lf == l.format {
+				break
+			}
+			ff := l.format[i+1:]
+			if l.hasPositions() {
+				f.positions = l.positions[i+1:]
+				l.positions = l.positions[:i+1]
+			}
+			l.format = lf
+			f.format = ff
+			l.follow = f
+			l = f
+		} else {
+			break
+		}
+	}
+	return r
+}
+
+// deepSplitDB 在每个动词与占位符之后拆分片段，每个片段最多对应一个参数
+func (di *DbInput) deepSplitDB(d *Dialect) *DbInput {
+	ss := di.chainSlots(d)
+	r := di.splitDB(ss[0])
+	k := 1
+	for l := di; l.follow != nil; l = l.follow {
+		r.last().follow = l.follow.splitDB(ss[k])
+		k++
+	}
+	return r
+}
+
+// splitDB 按 chainSlots 找到的动词与占位符拆分片段，参数与动词一一对应，跟随各自的动词
+func (di *DbInput) splitDB(slots []fmtVerb) *DbInput {
+	r := di.clone()
+	l := r
+	off := 0
+	n := 0
+	for _, s := range slots {
+		if s.verb != '?' {
+			n++
+		}
+		i := s.end - off
+		if i >= len(l.format) {
+			break
+		}
+		f := l.clone()
+		if n > len(l.paras) {
+			n = len(l.paras)
+		}
+		f.paras = l.paras[n:]
+		l.paras = l.paras[:n:n]
+		n = 0
+		if l.hasPositions() {
+			f.positions = l.positions[i:]
+			l.positions = l.positions[:i]
+		}
+		f.format = l.format[i:]
+		l.format = l.format[:i]
+		l.follow = f
+		l = f
+		off = s.end
+	}
+	return r
+}
+
+func (di *DbInput) getFirstEmpty() *DbInput {
+	l := di
+	for l != nil {
+		if len(l.paras) == 0 && l.prepare == nil {
+			return l
+		} else {
+			l = l.follow
+		}
+	}
+	return nil
+}
+
+func (di *DbInput) addParameter(input *DbInput) *DbInput {
+	if (*input).next == nil {
+		return di.addParameterSingle(input)
+	} else {
+		n := (*input).next
+		for {
+			if n == input {
+				break
+			}
+			di = di.addParameterSingle(n)
+			n = (*n).next
+		}
+		return di
+	}
+}
+
+// addParameterSingle for sprintf
+func (di *DbInput) addParameterSingle(input *DbInput) *DbInput {
+	prepare := di.getFirstEmpty()
+	if prepare == nil {
+		panic(1)
+	}
+	prepare.prepare = input.deepclone()
+	return di
+}
+
+// commit 参数填入片段末尾的动词：%s、%v 原样替换，%q 加上引号后替换，其他动词输出的内容是安全的，
+// 动词保留在片段中，参数标记为已净化以便输出它的名字
+func (di *DbInput) commit() {
+	if di.prepare != nil {
+		v, _ := di.lastVerb()
+		if injectable(v.verb) {
+			di.replaceVerb(v.start)
+		} else if v.verb == 'q' {
+			di.prepare = quoteInput(di.prepare)
+			di.replaceVerb(v.start)
+		} else if p := di.prepare; di.format[v.start:] == "%d" && p.follow == nil && p.next == nil &&
+			len(p.paras) == 0 && isDigits(strings.TrimPrefix(p.format, "-")) {
+			// %d 填入整数常量，输出与常量相同
+			di.replaceVerb(v.start)
+		} else {
+			p := &Param{sanitized: true}
+			if ps := di.prepare.allParas(); len(ps) == 1 {
+				c := *ps[0]
+				c.sanitized = true
+				p = &c
+			}
+			di.prepare = nil
+			di.paras = []*Param{p}
+		}
+	}
+}
+
+// replaceVerb 用参数替换从start开始到片段末尾的动词
+func (di *DbInput) replaceVerb(start int) {
+	di.prepare.last().follow = di.follow
+	f := di.prepare.follow
+	format := di.format[:start] + di.prepare.format
+	var positions []token.Pos
+	if di.hasPositions() {
+		positions = joinPositions(di.positions[:start], true,
+			di.prepare.positions, di.prepare.hasPositions())
+	}
+	*di = *(di.prepare)
+	di.positions = positions
+	di.follow = f
+	di.format = format
+}
+
+// commitDB 片段以占位符结尾时参数是绑定参数，不会拼接进sql
+func (di *DbInput) commitDB(slots []fmtVerb) {
+	if di.prepare != nil {
+		if len(slots) > 0 && slots[0].verb == '?' {
+			di.prepare = nil
+			di.paras = []*Param{}
+		} else {
+			di.commit()
+		}
+	}
+}
+
+func (di *DbInput) deepCommit() {
+	l := di
+	f := l.follow
+	for ; l != nil; l = f {
+		l.commit()
+		f = l.follow
+	}
+}
+
+func (di *DbInput) deepCommitDB(d *Dialect) {
+	ss := di.chainSlots(d)
+	l := di
+	f := l.follow
+	for k := 0; l != nil; l = f {
+		f = l.follow
+		l.commitDB(ss[k])
+		k++
+	}
+}
+
+// reportError 分析SQL注入的错误，报告最危险的一个被拼接的参数所在的上下文以及在源码中的位置
+func (di *DbInput) reportError(fun string, paras []Param, fset *token.FileSet, d *Dialect) *Finding {
+	injected, positions := di.injections(paras)
+	slots := di.sqlSlots(d)
+	injected, positions = exceptIdentifiers(injected, positions, slots, !ruleEnabled(RuleIdentifier))
+	if len(injected) == 0 {
+		return nil
+	}
+	f := &Finding{
+		Rule:    RuleSQLInjection,
+		Func:    fun,
+		Message: "exist sql injection",
+	}
+	pos := positions[0]
+	best := -1
+	for _, slot := range slots {
+		for _, p := range injected {
+			if slot.para != p {
+				continue
+			}
+			if c := contextRank[slot.context]; best < 0 || c.rank < best {
+				best = c.rank
+				f.Context = slot.context
+				f.Severity = c.severity
+				f.Remediation = c.remediation
+				pos = slot.pos
+			}
+			break
+		}
+	}
+	if fset != nil && pos.IsValid() {
+		f.Pos = fset.Position(pos)
+	}
+	return f
+}
+
+// injectedParas 以%s方式拼接进sql的函数参数
+func (di *DbInput) injectedParas(paras []Param) []*Param {
+	r, _ := di.injections(paras)
+	return r
+}
+
+// injections 以%s方式拼接进sql的函数参数，以及它们所在的%s在源码中的位置
+// 片段中的参数与动词一一对应，绑定参数的占位符不会带有参数
+func (di *DbInput) injections(paras []Param) ([]*Param, []token.Pos) {
+	var r []*Param
+	var positions []token.Pos
+	if di.Empty() {
+		return r, positions
+	}
+
+	for loop := di; loop != nil; loop = loop.follow {
+		if len(loop.paras) > 0 {
+			for i, para := range loop.paras {
+				if para == nil || para.sanitized {
+					continue
+				}
+				if at, c, ok := loop.getFormatPos(i); ok {
+					if injectable(c) && taintedBy(para, paras) {
+						r = append(r, para)
+						positions = append(positions, loop.posAt(at))
+					}
+				}
+			}
+		}
+	}
+	return r, positions
+}
+
+// taintedBy 参数来自函数的参数或者它的字段，类型安全的参数除外
+func taintedBy(para *Param, paras []Param) bool {
+	for _, p := range paras {
+		if p.pName == "_" {
+			continue
+		}
+		if para.pName == p.pName && isSafeTypeName(p.pType) {
+			return false
+		}
+		if para.pName == p.pName ||
+			strings.Index(para.pName, p.pName+".") == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ExtraceName 通用的，无需进行扩展的类，可以抓取出函数参数的类型
+*/
+type ExtraceName struct {
+	result    string
+	caseStack *list.List
+}
+
+func NewExtraceName() *ExtraceName {
+	return &ExtraceName{
+		caseStack: list.New()}
+}
+
+func (en *ExtraceName) Visit(n ast.Node) ast.Visitor {
+	if n == nil {
+		en.upDateStateAfterPop()
+		return nil
+	} else {
+		en.caseStack.PushBack(n)
+		switch t := n.(type) {
+		case *ast.Ident:
+			{
+				en.result = en.result + t.Name
+			}
+		case *ast.StarExpr:
+			{
+				en.result = en.result + "*"
+			}
+		case *ast.ArrayType:
+			{
+				en.result = en.result + "[]"
+			}
+		}
+	}
+	return en
+}
+
+func (en *ExtraceName) upDateStateAfterPop() {
+	e := en.caseStack.Back()
+	en.caseStack.Remove(e)
+	// a.b.c 中每一层 SelectorExpr 的 X 结束后都需要加 "."
+	if last := en.caseStack.Back(); last != nil {
+		if se, ok := last.Value.(*ast.SelectorExpr); ok && se.X == e.Value {
+			en.result = en.result + "."
+		}
+	}
+}
+
+// Analyzer sql注入分析器，使用 NewAnalyzer 创建
+type Analyzer struct {
+	Deps             bool // 同时计算非标准库依赖包的函数摘要
+	Inventory        bool // 记录每个数据库调用重建的语句，见 WriteInventory
+	Verbose          bool // 打印每条路径上到达数据库调用的sql
+	ignoreNosec      bool
+	catchError       bool
+	caseStack        *list.List
+	parameters       []Param
+	curParaName      string
+	curParaType      string
+	state            int
+	curFunName       string
+	logger           *log.Logger
+	dbCallPara       map[string]string
+	allPossibleInput map[string]*DbInput
+	result           []*Finding
+	inventory        []*inventoryEntry // -inventory 输出的所有语句
+	pkg              *packages.Package
+	summaries        *summaryStore
+	summary          *FuncSummary
+	quiet            bool
+	guardVars        map[string]ast.Expr
+	builders         map[string]bool
+	localExprs       map[string]ast.Expr
+	owners           map[ast.Expr]ast.Stmt
+	reported         map[findingKey]bool
+	argCounts        map[token.Pos]*argCount // 数据库调用的占位符个数，函数分析结束时报告
+	dialect          *Dialect                // 正在分析的数据库调用使用的方言
+	litDepth         int
+}
+
+// NewAnalyzer 创建分析器，summaryFile 为空时不读取也不保存函数摘要
+func NewAnalyzer(summaryFile string) *Analyzer {
+	return &Analyzer{
+		catchError: false,
+		logger:     log.New(os.Stderr, "[sqlinj]", log.LstdFlags),
+		caseStack:  list.New(),
+		//parameters:       make([]Param,1),
+		state:            StateMentAnalysisSTART,
+		allPossibleInput: make(map[string]*DbInput),
+		dbCallPara:       make(map[string]string),
+		summaries:        newSummaryStore(summaryFile),
+		guardVars:        make(map[string]ast.Expr),
+		builders:         make(map[string]bool),
+		localExprs:       make(map[string]ast.Expr),
+		reported:         make(map[findingKey]bool),
+		owners:           make(map[ast.Expr]ast.Stmt),
+		argCounts:        make(map[token.Pos]*argCount),
+	}
+}
+
+// Findings 分析发现的所有问题，按发现的顺序
+func (si *Analyzer) Findings() []*Finding {
+	return si.result
+}
+
+// funcParameters 函数的参数，a, b string 展开为两个参数，没有名字的参数记为 _，下标与调用处的实参一致
+func funcParameters(ft *ast.FuncType) []Param {
+	var r []Param
+	for _, field := range ft.Params.List {
+		en := NewExtraceName()
+		ast.Walk(en, field.Type)
+		if len(field.Names) == 0 {
+			r = append(r, Param{pName: "_", pType: en.result})
+			continue
+		}
+		for _, name := range field.Names {
+			r = append(r, Param{pName: name.Name, pType: en.result})
+		}
+	}
+	return r
+}
+
+// fork 用于按需计算被调用函数摘要的子分析器，与父分析器共享摘要表，不输出结果
+func (si *Analyzer) fork() *Analyzer {
+	return &Analyzer{
+		logger:           si.logger,
+		caseStack:        list.New(),
+		state:            StateMentAnalysisSTART,
+		allPossibleInput: make(map[string]*DbInput),
+		dbCallPara:       make(map[string]string),
+		guardVars:        make(map[string]ast.Expr),
+		builders:         make(map[string]bool),
+		localExprs:       make(map[string]ast.Expr),
+		owners:           make(map[ast.Expr]ast.Stmt),
+		reported:         make(map[findingKey]bool),
+		argCounts:        make(map[token.Pos]*argCount),
+		pkg:              si.pkg,
+		summaries:        si.summaries,
+		quiet:            true,
+	}
+}
+
+// summaryOf 被调用函数的摘要，同一个包内尚未分析的函数会立即计算
+func (si *Analyzer) summaryOf(call *ast.CallExpr) *FuncSummary {
+	if si.summaries == nil {
+		return nil
+	}
+	key := calleeKey(si.pkg, call)
+	if key == "" {
+		return nil
+	}
+	if s, ok := si.summaries.funcs[key]; ok {
+		return s
+	}
+	if decl, ok := si.summaries.decls[key]; ok && !si.summaries.pending[key] {
+		ast.Walk(si.fork(), decl)
+	}
+	return si.summaries.funcs[key]
+}
+
+// applyCalleeSinks 实参流入被调用函数的数据库调用时，对应的本函数参数同样到达数据库调用
+func (si *Analyzer) applyCalleeSinks(call *ast.CallExpr, callee *FuncSummary) {
+	if !callee.acceptsArgs(call) {
+		return
+	}
+	for _, i := range callee.Sinks {
+		for _, e := range callee.argsOf(call, i) {
+			arg := si.getDbInputFromRhs(e)
+			for _, name := range arg.paraNames() {
+				if j, _ := paramIndex(si.parameters, name); j >= 0 {
+					si.summary.addSink(j)
+				}
+			}
+		}
+	}
+}
+
+// addReturnSummary 记录返回值的形状
+func (si *Analyzer) addReturnSummary(ret *ast.ReturnStmt) {
+	n := len(ret.Results)
+	if n == 1 {
+		// return f() 返回f的多个返回值
+		n = si.resultCount(ret.Results[0])
+	}
+	for i, di := range si.values(ret.Results, n) {
+		if di.Empty() {
+			continue
+		}
+		if fragments := summaryFragments(di, si.parameters); len(fragments) > 0 {
+			si.summary.addReturn(&SummaryReturn{Index: i, Fragments: fragments})
+		}
+	}
+}
+
+func (si *Analyzer) isFunctionParaName() bool {
+	last := si.caseStack.Back() // type is []*ast.Ident
+	if last = last.Prev(); last != nil {
+		switch last.Value.(type) {
+		case *ast.FieldList:
+			if last = last.Prev(); last != nil {
+				switch last.Value.(type) {
+				case *ast.FuncType:
+					if last = last.Prev(); last != nil {
+						switch last.Value.(type) {
+						case *ast.FuncDecl:
+							return true
+						}
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (si *Analyzer) isSprintfCall(n ast.Node) bool {
+	switch node := n.(type) {
+	case *ast.CallExpr:
+		//switch f := node.Fun.(type){
+		switch node.Fun.(type) {
+		case *ast.SelectorExpr:
+			return true
+			//if f.Sel.Name == "Sprintf"{
+			//	return true
+			//}
+		}
+	}
+	return false
+}
+
+// ChangeState 切换分析器状态
+func (si *Analyzer) ChangeState(s int) {
+	//fmt.Println("state change from  " , si.state, " to ", s)
+	si.state = s
+}
+
+// todo : delete
+func getParaType(f ast.Field) string {
+	result := ""
+	switch t := f.Type.(type) {
+	case *ast.Ident:
+		{
+			return t.Name
+		}
+	case *ast.StarExpr:
+		{
+			result = "*"
+			switch seType := t.X.(type) {
+			case *ast.SelectorExpr:
+				switch xt := seType.X.(type) {
+				case *ast.Ident:
+					result = result + xt.Name
+				default:
+					panic(xt)
+				}
+				result = result + "." + seType.Sel.Name
+			}
+		}
+	case *ast.SelectorExpr:
+		{
+			switch xt := t.X.(type) {
+			case *ast.Ident:
+				result = result + xt.Name
+			default:
+				panic(xt)
+			}
+			result = result + "." + t.Sel.Name
+		}
+	case *ast.ArrayType:
+		{
+			switch Elt := t.Elt.(type) {
+			case *ast.Ident:
+				{
+					result = result + "[]" + Elt.Name
+				}
+			default:
+				panic(Elt)
+			}
+		}
+	default:
+		panic(t)
+	}
+
+	return result
+}
+
+// upDateStateAfterPop ast.walk为深度优先遍历，因此Analyzer使用了一个栈来管理状态，函数体由 checkFunc 按控制流图分析
+func (si *Analyzer) upDateStateAfterPop() {
+	lastElement := si.caseStack.Back()
+	si.caseStack.Remove(lastElement)
+}
+
+// isStringFormat 制定位置是否为 s%
+func isStringFormat(str string, pos int) bool {
+	count := 0
+	for i := 0; i+1 < len(str); i++ {
+		if str[i] == '%' {
+			count++
+		}
+
+		if count == pos {
+			if str[i] == 's' {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isFunctionParameters 变量是否是函数参数
+func (si *Analyzer) isFunctionParameters(v string) bool {
+	for _, para := range si.parameters {
+		if para.pName == v {
+			return true
+		}
+	}
+	return false
+}
+
+// isDbCallFunction 是否数据库相关函数
+func (si *Analyzer) isDbCallFunction() bool {
+	for _, para := range si.parameters {
+		if para.pType == "*sqlx.DB" ||
+			para.pType == "kitSql.DbInterface" {
+			return true
+		}
+	}
+	return false
+}
+
+func (si *Analyzer) getDbInputFromToken(token string) *DbInput {
+	if k, ok := si.allPossibleInput[token]; ok {
+		return k
+	} else {
+		return &DbInput{
+			format: "%s",
+			paras:  []*Param{&Param{pName: token}},
+		}
+	}
+}
+
+// getDbInputFromRhs 分析表达式的右值，将其转化为*DbInput结构以便运算，分析, 需要不断完善，支持所有字符串操作
+func (si *Analyzer) getDbInputFromRhs(n ast.Node) *DbInput {
+	di := &DbInput{}
+	switch rhs := n.(type) {
+	case *ast.CallExpr:
+		if arg := si.conversionArg(rhs); arg != nil {
+			// string(b)、[]byte(q)、SQL(q) 等转换保留原来的片段
+			return si.getDbInputFromRhs(arg)
+		}
+		if si.isSanitizerCall(rhs) {
+			return si.getDbInputFromSanitizer(rhs)
+		}
+		if si.isLikeEscapeCall(rhs) {
+			return si.getDbInputFromLikeEscape(rhs)
+		}
+		if b := si.getDbInputFromBuilder(rhs); b != nil {
+			return b
+		}
+		if isBuilderInit(rhs) {
+			// bytes.NewBufferString(s) 以s为初始内容
+			if len(rhs.Args) == 1 {
+				return si.getDbInputFromRhs(rhs.Args[0])
+			}
+			return di
+		}
+		switch fn := rhs.Fun.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := fn.X.(*ast.Ident); ok {
+				if x.Name == "fmt" && fn.Sel.Name == "Sprintf" {
+					di = si.getDbInputFromSprintf(rhs.Args)
+				} else if x.Name == "strings" && fn.Sel.Name == "Join" {
+					di = si.getDbInputFromRhs(rhs.Args[0])
+					if !di.isCollection() {
+						// 无法展开的切片，如参数 ids，连接后的内容与切片本身一样
+						if len(di.paras) > 0 || di.follow != nil {
+							return di
+						}
+						di = &DbInput{}
+						(*di).next = di
+					}
+					di = di.cloneCollection()
+					join := si.getDbInputFromRhs(rhs.Args[1])
+					(*di).likeStringJoin(join)
+					di = di.merge()
+				} else if r, ok := si.getDbInputFromStdlib(rhs, x.Name, fn.Sel.Name); ok {
+					di = r
+				}
+			}
+		case *ast.Ident:
+			if fn.Name == "append" && len(rhs.Args) > 0 && si.isBytesExpr(rhs.Args[0]) {
+				// append(b, s...) 向 []byte 追加内容
+				var r *DbInput
+				for _, arg := range rhs.Args {
+					r = concatInput(r, si.getDbInputFromRhs(arg))
+				}
+				di = r
+			} else if fn.Name == "append" {
+				for i, arg := range rhs.Args {
+					if i == 0 {
+						di = si.getDbInputFromRhs(arg)
+						if !di.isCollection() { // todo delete
+							di = &DbInput{}
+							(*di).next = di
+						}
+						di = di.cloneCollection()
+					} else if new := si.getDbInputFromRhs(arg); rhs.Ellipsis.IsValid() && new.isCollection() {
+						// append(args, ids...) 逐个追加元素
+						for n := new.next; n != new; n = n.next {
+							(*di).appendTail(n.deepclone())
+						}
+					} else {
+						(*di).appendTail(new.deepclone())
+					}
+				}
+			} else if fn.Name == "make" && len(rhs.Args) > 0 && isStringSlice(rhs.Args[0]) {
+				// make([]string, n) 之后由 marks[i] = "?" 填充
+				di = &DbInput{}
+				(*di).next = di
+			}
+		}
+		if di.Empty() {
+			di = si.getDbInputFromCallee(rhs, 0)
+		}
+	case *ast.BinaryExpr:
+		if rhs.Op == token.ADD {
+			X := si.getDbInputFromRhs(rhs.X)
+			Y := si.getDbInputFromRhs(rhs.Y)
+			di = X.add(Y)
+		}
+	case *ast.BasicLit:
+		return getDbInputFromLit(rhs)
+	case *ast.Ident:
+		if c, ok := si.constArg(rhs); ok {
+			// 局部或包级的字符串常量
+			return &DbInput{
+				format: c,
+			}
+		}
+		if k, ok := si.allPossibleInput[rhs.Name]; ok {
+			return k
+		} else if si.isSafeExpr(rhs) {
+			return safeParaInput(rhs.Name)
+		} else {
+			return &DbInput{
+				format:    "%s",
+				paras:     []*Param{&Param{pName: rhs.Name, enum: si.isEnumExpr(rhs)}},
+				positions: []token.Pos{rhs.Pos(), rhs.Pos()},
+			}
+		}
+	case *ast.SelectorExpr:
+		if c, ok := si.constArg(rhs); ok {
+			// 其他包的字符串常量 pkg.Query
+			return &DbInput{
+				format: c,
+			}
+		}
+		di = si.getDbInputFromName(n)
+	case *ast.IndexExpr:
+		if r := si.getDbInputFromIndex(rhs); r != nil {
+			return r
+		}
+		di = si.getDbInputFromName(n)
+	case *ast.SliceExpr:
+		di = si.getDbInputFromSlice(rhs)
+	case *ast.CompositeLit:
+		if rhs.Type != nil && (isStringSlice(rhs.Type) || isStringMap(rhs.Type)) {
+			// []string{}、[]interface{}{} 与 map[K]string{}
+			di = &DbInput{}
+			(*di).next = di
+			// 字面量中的元素，如 []interface{}{id, name}，map 取其中的值
+			if di.isCollection() {
+				for _, elt := range rhs.Elts {
+					if kv, ok := elt.(*ast.KeyValueExpr); ok {
+						if !isStringMap(rhs.Type) {
+							continue
+						}
+						elt = kv.Value
+					}
+					(*di).appendTail(si.getDbInputFromRhs(elt).deepclone())
+				}
+			}
+		}
+	default:
+		di = si.getDbInputFromName(n)
+	}
+	return di
+}
+
+// getDbInputFromName 无法进一步分析的表达式，按名字查找已知的值，否则作为一个参数
+func (si *Analyzer) getDbInputFromName(n ast.Node) *DbInput {
+	di := &DbInput{}
+	en := NewExtraceName()
+	ast.Walk(en, n)
+	if en.result != "" {
+		//di.paras = append(di.paras, &Param{pName:en.result,})
+		if _, ok := si.allPossibleInput[en.result]; !ok &&
+			(si.isSafeExpr(n) || si.isTrustedField(n)) {
+			di = safeParaInput(en.result)
+		} else if f := si.fieldInput(en.result); f != nil {
+			di = f
+			di.positions = []token.Pos{n.Pos(), n.Pos()}
+		} else if k, ok := si.allPossibleInput[en.result]; ok {
+			di = k
+		} else {
+			di = si.getDbInputFromToken(en.result)
+			di.positions = []token.Pos{n.Pos(), n.Pos()}
+			if si.isEnumExpr(n) {
+				di.paras[0].enum = true
+			}
+		}
+	}
+	return di
+}
+
+// fieldInput f.Col 中的f绑定到另一个参数时（如range的变量），字段作为那个参数的字段
+func (si *Analyzer) fieldInput(name string) *DbInput {
+	if _, ok := si.allPossibleInput[name]; ok {
+		return nil
+	}
+	i := strings.Index(name, ".")
+	if i <= 0 {
+		return nil
+	}
+	v, ok := si.allPossibleInput[name[:i]]
+	if !ok || v.follow != nil || v.next != nil || len(v.paras) != 1 || v.paras[0] == nil {
+		return nil
+	}
+	p := *v.paras[0]
+	p.pName = p.pName + name[i:]
+	return &DbInput{
+		format: "%s",
+		paras:  []*Param{&p},
+	}
+}
+
+// getDbInputFromSprintf fmt.Sprintf 的参数，第一个为格式，其余依次填入格式中的 %x
+func (si *Analyzer) getDbInputFromSprintf(args []ast.Expr) *DbInput {
+	if len(args) == 0 {
+		return &DbInput{}
+	}
+	format := si.getDbInputFromRhs(args[0])
+	paras := []*DbInput{}
+	for _, arg := range args[1:] {
+		paras = append(paras, si.getDbInputFromRhs(arg))
+	}
+	return sprintfInputs(format, paras)
+}
+
+// sprintfInputs 按 fmt.Sprintf 的方式将参数依次填入格式
+func sprintfInputs(format *DbInput, paras []*DbInput) *DbInput {
+	di := &DbInput{}
+	// ad format
+	di = di.addFormat(format)
+	// args... 展开为每个元素
+	args := []*DbInput{}
+	for _, p := range paras {
+		if !p.isCollection() {
+			args = append(args, p)
+			continue
+		}
+		for n := p.next; n != nil && n != p; n = n.next {
+			args = append(args, n)
+		}
+	}
+	// 每个动词按 fmt 的规则取参数，%[2]s 指定下标，宽度与精度的 * 也消耗参数
+	argNum := 0
+	for l := di; l != nil; l = l.follow {
+		if len(l.paras) > 0 {
+			continue
+		}
+		var vs []fmtVerb
+		vs, argNum = parseFormat(l.format, argNum)
+		if len(vs) == 0 {
+			continue
+		}
+		// add parameter
+		if v := vs[len(vs)-1]; v.arg >= 0 && v.arg < len(args) && v.end == len(l.format) {
+			l.prepare = args[v.arg].deepclone()
+		}
+	}
+	di.deepCommit()
+	return di
+}
+
+// getDbInputFromCallee 被调用函数在分析范围内时，用其摘要中第index个返回值的形状代替调用表达式
+func (si *Analyzer) getDbInputFromCallee(call *ast.CallExpr, index int) *DbInput {
+	callee := si.summaryOf(call)
+	if callee == nil || !callee.acceptsArgs(call) {
+		return &DbInput{}
+	}
+	ret := callee.returnAt(index)
+	if ret == nil {
+		return &DbInput{}
+	}
+	args := []*DbInput{}
+	for _, arg := range call.Args {
+		args = append(args, si.getDbInputFromRhs(arg))
+	}
+	return ret.instantiate(callee.shortName(), args)
+}
+
+// AddDbCallPara 判断参数的类型是否是数据库调用接口
+func (si *Analyzer) AddDbCallPara(n string, t string) {
+	if !(t == "*sqlx.DB" ||
+		t == "*sqlx.Tx" ||
+		t == "sql.DbInterface" ||
+		t == "kitSql.DbInterface") {
+		return
+	}
+	if _, ok := si.dbCallPara[n]; ok {
+		return
+	}
+	si.dbCallPara[n] = t
+}
+
+// isDbInterfaceCall 判断调用是否是数据调用
+func (si *Analyzer) isDbInterfaceCall(n *ast.CallExpr) (string, string, bool) {
+	if f, ok := n.Fun.(*ast.SelectorExpr); ok {
+		if x, ok := f.X.(*ast.Ident); ok {
+			if v, ok := si.dbCallPara[x.Name]; ok {
+				return v, f.Sel.Name, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func (si *Analyzer) analyzeDbCall(di *DbInput, ce *ast.CallExpr, index int) *DbInput {
+	var binds []*DbInput
+	for i, arg := range ce.Args {
+		if i == index {
+			addFormat := si.getDbInputFromRhs(arg)
+			si.reachSink(addFormat)
+			si.checkArgCount(ce, index, addFormat)
+			di = (*di).addFormatDb(addFormat, si.dialect)
+		} else if i > index {
+			addPara := si.getDbInputFromRhs(arg)
+			si.reachSink(addPara)
+			di = (*di).addParameter(addPara)
+			binds = appendBinds(binds, addPara, ce.Ellipsis.IsValid() && i == len(ce.Args)-1)
+		}
+	}
+	di.deepCommitDB(si.dialect)
+	di.binds = binds
+	return di
+}
+
+// reachSink 记录到达数据库调用的函数参数，未被拼接进sql的参数在摘要中视为已净化
+func (si *Analyzer) reachSink(di *DbInput) {
+	if si.summary == nil {
+		return
+	}
+	for _, name := range di.paraNames() {
+		if i, _ := paramIndex(si.parameters, name); i >= 0 {
+			si.summary.addSanitized(i)
+		}
+	}
+}
+
+// checkDbCall 工具检测到是数据库调用接口时，就会根据不同的接口，分析那些事格式字符串，哪些是参数并进行分析，此函数需要不断维护，增加类型
+func (si *Analyzer) checkDbCall(node *ast.CallExpr, iType string, fName string) {
+	di := &DbInput{}
+	si.dialect = dialectOf(iType)
+	switch iType {
+	case "*sqlx.DB":
+		switch fName {
+		case "Get", "Select":
+			di = si.analyzeDbCall(di, node, 1)
+		case "Queryx":
+			di = si.analyzeDbCall(di, node, 0)
+
+		}
+	case "*sqlx.Tx":
+		switch fName {
+		case "Exec":
+			di = si.analyzeDbCall(di, node, 0)
+		case "Get":
+			di = si.analyzeDbCall(di, node, 1)
+		}
+	case "sql.DbInterface":
+		switch fName {
+		case "Get":
+			di = si.analyzeDbCall(di, node, 1)
+		}
+	case "kitSql.DbInterface":
+		switch fName {
+		case "Get":
+			di = si.analyzeDbCall(di, node, 1)
+		case "Exec":
+			di = si.analyzeDbCall(di, node, 0)
+		}
+	}
+
+	if si.summary != nil {
+		for _, p := range di.injectedParas(si.parameters) {
+			if i, _ := paramIndex(si.parameters, p.pName); i >= 0 {
+				si.summary.addSink(i)
+			}
+		}
+	}
+	if si.quiet {
+		return
+	}
+
+	if si.Verbose {
+		// 每条路径都会打印一次
+		fmt.Println("final di is ")
+		fmt.Println(di.toString())
+	}
+
+	q := si.query(node, iType, fName, di)
+	si.checkRules(q)
+	if si.Inventory {
+		si.recordInventory(q)
+	}
+}
+
+// Visit sql注入分析实现的给walk用的Vist函数
+func (si *Analyzer) Visit(n ast.Node) ast.Visitor {
+	defer func() {
+		//fmt.Println("catch error")
+		if err := recover(); err != nil {
+			fmt.Println(err)
+			si.catchError = true
+		}
+	}()
+
+	if n == nil {
+		si.upDateStateAfterPop()
+		//fmt.Printf("pop len is %d\n", si.caseStack.Len())
+		return nil
+	} else {
+		switch node := n.(type) {
+		case *ast.FuncDecl:
+			// 函数体按控制流图分析，不再继续遍历
+			if si.state == StateMentAnalysisSTART {
+				si.checkFunc(node)
+			}
+			return nil
+		}
+		si.caseStack.PushBack(n)
+		//fmt.Printf("push len is %d\n", si.caseStack.Len())
+		return si
+	}
+}
+
+// checkFunc 记录函数参数，按控制流图分析函数体，结束后保存函数摘要
+func (si *Analyzer) checkFunc(node *ast.FuncDecl) {
+	si.curFunName = node.Name.Name
+	si.catchError = false
+	if !si.quiet {
+		fmt.Println("check " + si.curFunName)
+	}
+	si.ChangeState(StateMentAnalysisFUNCTION)
+	defer si.endFunc()
+	si.parameters = funcParameters(node.Type)
+	for _, para := range si.parameters {
+		si.AddDbCallPara(para.pName, para.pType)
+	}
+	if si.summaries != nil && si.pkg != nil {
+		si.summary = &FuncSummary{Func: funcDeclKey(si.pkg, node)}
+		for _, p := range si.parameters {
+			si.summary.Params = append(si.summary.Params, p.pName)
+		}
+		if n := len(node.Type.Params.List); n > 0 {
+			_, si.summary.Variadic = node.Type.Params.List[n-1].Type.(*ast.Ellipsis)
+		}
+		si.summaries.pending[si.summary.Func] = true
+	}
+	if node.Body == nil {
+		return
+	}
+	si.ChangeState(StateMentAnalysisFUNCTIONBODY)
+	si.analyzeBody(node.Body, []flowState{{}})
+	si.ChangeState(StateMentAnalysisFUNCTION)
+}
+
+// endFunc 保存函数摘要并清理函数内的状态，分析出错时同样需要清理
+func (si *Analyzer) endFunc() {
+	if si.summary != nil {
+		delete(si.summaries.pending, si.summary.Func)
+		si.summaries.put(si.pkg.PkgPath, si.summary)
+		si.summary = nil
+	}
+	si.reportArgCounts()
+	si.argCounts = make(map[token.Pos]*argCount)
+	si.parameters = nil
+	si.curFunName = ""
+	si.allPossibleInput = make(map[string]*DbInput)
+	si.dbCallPara = make(map[string]string)
+	si.guardVars = make(map[string]ast.Expr)
+	si.builders = make(map[string]bool)
+	si.localExprs = make(map[string]ast.Expr)
+	si.owners = make(map[ast.Expr]ast.Stmt)
+	si.litDepth = 0
+	si.ChangeState(StateMentAnalysisSTART)
+}
+
+// handleCall 函数体中的调用：数据库调用、builder写入、模板渲染，或者参数流入被调用函数的数据库调用
+func (si *Analyzer) handleCall(node *ast.CallExpr) {
+	if iType, fName, ok := si.isDbInterfaceCall(node); ok {
+		si.checkDbCall(node, iType, fName)
+	} else if !si.builderWrite(node) && !si.templateExecute(node) && si.summary != nil {
+		if callee := si.summaryOf(node); callee != nil {
+			si.applyCalleeSinks(node, callee)
+		}
+	}
+}
+
+// assign 赋值语句更新当前路径上变量的值
+func (si *Analyzer) assign(node *ast.AssignStmt) {
+	si.recordGuardVar(node)
+	si.recordBuilderAssign(node)
+	si.recordLocalExpr(node)
+	// del right
+	// 处理 += 操作
+	if node.Tok == token.ADD_ASSIGN {
+		dbInput := si.getDbInputFromRhs(node.Rhs[0])
+		if !dbInput.Empty() {
+			// 变量或者结构体字段 p.X，字段单独记录以区分同一个参数的不同字段
+			if name := exprName(node.Lhs[0]); name != "" {
+				left := si.getDbInputFromRhs(node.Lhs[0])
+				if !left.Empty() {
+					si.allPossibleInput[name] = left.add(dbInput)
+					//fmt.Println("allPossibleInput add += ", v.Name, ":", left)
+				}
+			}
+		}
+	} else {
+		// a, b := x, y 与 q, args := build() 的每个位置，右值全部求值后再赋值
+		for i, dbInput := range si.values(node.Rhs, len(node.Lhs)) {
+			if index, ok := node.Lhs[i].(*ast.IndexExpr); ok {
+				si.assignElement(index, dbInput)
+			} else if !dbInput.Empty() {
+				if name := exprName(node.Lhs[i]); name != "" && name != "_" {
+					si.allPossibleInput[name] = dbInput
+					//fmt.Println("allPossibleInput add ", v.Name, ":", dbInput)
+				}
+			}
+		}
+	}
+}
+
+// declare var q = "..." 与 var a, b = x, y 形式的声明
+func (si *Analyzer) declare(vs *ast.ValueSpec) {
+	for i, dbInput := range si.values(vs.Values, len(vs.Names)) {
+		if !dbInput.Empty() && vs.Names[i].Name != "_" {
+			si.allPossibleInput[vs.Names[i].Name] = dbInput
+		}
+	}
+}
+
+// values 赋值、声明或返回语句中每个位置的值，n 为位置的个数，只有一个调用时第i个位置取被调用函数的第i个返回值
+func (si *Analyzer) values(exprs []ast.Expr, n int) []*DbInput {
+	r := make([]*DbInput, n)
+	for i := range r {
+		switch {
+		case len(exprs) == n:
+			r[i] = si.getDbInputFromRhs(exprs[i])
+		case len(exprs) == 1 && i == 0:
+			r[i] = si.getDbInputFromRhs(exprs[0])
+		case len(exprs) == 1:
+			r[i] = &DbInput{}
+			if call, ok := exprs[0].(*ast.CallExpr); ok {
+				r[i] = si.getDbInputFromCallee(call, i)
+			}
+		default:
+			r[i] = &DbInput{}
+		}
+	}
+	return r
+}
+
+// resultCount 调用表达式返回值的个数
+func (si *Analyzer) resultCount(e ast.Expr) int {
+	if _, ok := e.(*ast.CallExpr); ok && si.pkg != nil && si.pkg.TypesInfo != nil {
+		if t, ok := si.pkg.TypesInfo.TypeOf(e).(*types.Tuple); ok {
+			return t.Len()
+		}
+	}
+	return 1
+}
+
+func (si *Analyzer) check(pkg *packages.Package) {
+	si.pkg = pkg
+	if si.summaries != nil {
+		si.summaries.index(pkg)
+	}
+	for _, file := range pkg.Syntax {
+		//si.logger.Println("Checking file:", pkg.Fset.File(file.Pos()).Name())
+		ast.Walk(si, file)
+	}
+}
+
+// GetPkgAbsPath returns the Go package absolute path derived from
+// the given path
+func GetPkgAbsPath(pkgPath string) (string, error) {
+	absPath, err := filepath.Abs(pkgPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return "", errors.New("no project absolute path found")
+	}
+	return absPath, nil
+}
+
+func (si *Analyzer) load(pkgPath string, conf *packages.Config) ([]*packages.Package, error) {
+	abspath, err := GetPkgAbsPath(pkgPath)
+	if err != nil {
+		si.logger.Printf("Skipping: %s. Path doesn't exist.", abspath)
+		return []*packages.Package{}, nil
+	}
+
+	si.logger.Println("Import directory:", abspath)
+	basePackage, err := build.Default.ImportDir(pkgPath, build.ImportComment)
+	if err != nil {
+		si.logger.Println("Import err:", err)
+		return []*packages.Package{}, fmt.Errorf("importing dir %q: %v", pkgPath, err)
+	}
+
+	if len(basePackage.GoFiles) == 0 {
+		return []*packages.Package{}, nil
+	}
+
+	/*
+		if si.tests {
+			testsFiles := []string{}
+			testsFiles = append(testsFiles, basePackage.TestGoFiles...)
+			testsFiles = append(testsFiles, basePackage.XTestGoFiles...)
+			for _, filename := range testsFiles {
+				packageFiles = append(packageFiles, path.Join(pkgPath, filename))
+			}
+		}
+	*/
+
+	// 按目录加载而不是按文件加载，这样包保留真实的导入路径，函数摘要以此为键在包之间共享
+	pkgs, err := packages.Load(conf, abspath)
+	if err != nil {
+		return []*packages.Package{}, fmt.Errorf("loading files from package %q: %v", pkgPath, err)
+	}
+	return pkgs, nil
+}
+
+func (gosec *Analyzer) pkgConfig(buildTags []string) *packages.Config {
+	flags := []string{}
+	if len(buildTags) > 0 {
+		tagsFlag := "-tags=" + strings.Join(buildTags, " ")
+		flags = append(flags, tagsFlag)
+	}
+
+	return &packages.Config{
+		Mode: packages.LoadSyntax,
+		//Mode:       packages.LoadFiles,
+		BuildFlags: flags,
+		Tests:      false,
+	}
+}
+
+func (si *Analyzer) Process(buildTags []string, packagePaths []string) error {
+	config := si.pkgConfig(buildTags)
+	var targets []*packages.Package
+	for _, pkgPath := range packagePaths {
+
+		pkgs, err := si.load(pkgPath, config)
+		if err != nil {
+			//si.AppendError(pkgPath, err)
+			fmt.Println(pkgPath, " load error:", err)
+		}
+		for _, pkg := range pkgs {
+			// todo : analyze load error
+			if pkg.Name != "" {
+				targets = append(targets, pkg)
+			}
+		}
+	}
+	si.checkPackages(targets, config)
+	//sortErrors(si.errors)
+	return nil
+}
+
+// CheckPackages 分析已经加载的包，包需要带有语法树与类型信息
+func (si *Analyzer) CheckPackages(pkgs []*packages.Package) {
+	si.checkPackages(pkgs, nil)
+}
+
+// checkPackages 按依赖顺序分析，被依赖的包的函数摘要先于依赖它的包计算
+func (si *Analyzer) checkPackages(targets []*packages.Package, config *packages.Config) {
+	if si.summaries == nil {
+		for _, pkg := range sortPackages(targets) {
+			si.check(pkg)
+		}
+		return
+	}
+	if err := si.summaries.load(); err != nil {
+		si.logger.Println("load summaries err:", err)
+	}
+	for _, pkg := range targets {
+		si.summaries.fingerprint(pkg)
+	}
+	if si.Deps {
+		si.summarizeDeps(dependencies(targets), config)
+	}
+	for _, pkg := range sortPackages(targets) {
+		si.check(pkg)
+	}
+	if err := si.summaries.save(); err != nil {
+		si.logger.Println("save summaries err:", err)
+	}
+}
+
+// summarizeDeps 只计算依赖包的摘要，不报告其中的问题；指纹与缓存一致的包直接复用
+func (si *Analyzer) summarizeDeps(deps []*packages.Package, config *packages.Config) {
+	var paths []string
+	for _, dep := range deps {
+		if !si.summaries.reuse(dep.PkgPath) {
+			paths = append(paths, dep.PkgPath)
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	pkgs, err := packages.Load(config, paths...)
+	if err != nil {
+		si.logger.Println("load dependencies err:", err)
+		return
+	}
+	for _, pkg := range sortPackages(pkgs) {
+		if pkg.Name == "" {
+			continue
+		}
+		child := si.fork()
+		child.check(pkg)
+	}
+}
+
+func (si *Analyzer) CheckDir(d string) {
+	paths, err := getPackagePaths(d)
+	if err != nil {
+		fmt.Println(err)
+	}
+	var buildTags []string
+	si.Process(buildTags, paths)
+}
+
+func unittest1() {
+	fmt.Println("-------------------")
+	s1 := "SELECT %s FROM %s b_sum"
+	s2 := "%s"
+
+	di1 := &DbInput{
+		format: s1,
+		//paras: [] *Param {&Param{pName:"p1",},},
+	}
+
+	fmt.Println("di1 :", di1)
+	di1 = di1.split()
+	fmt.Println("di1 :", di1)
+	fmt.Println("di1 merge :", di1.mergePureFormat())
+
+	di2 := &DbInput{
+		format: s2,
+		paras:  []*Param{&Param{pName: "p2"}},
+	}
+
+	fmt.Println("di2 :", di2)
+	di3 := di1.add(di2)
+	di4 := di3.add(di1)
+	fmt.Println("di1 + di2 :", di1.add(di2))
+	fmt.Println("di3 :", di3)
+	fmt.Println("di4 :", di4)
+
+	di5 := di4.deepclone()
+	di4 = di4.add(di1)
+	fmt.Println("di5 :", di5)
+	fmt.Println("di4 :", di4)
+
+	di5 = di5.add(di1)
+	di5 = di5.add(di2)
+	di5 = di5.add(di1)
+	di6 := di5.mergePureFormat()
+	fmt.Println("di5 :", di5)
+	fmt.Println("di6 :", di6)
+	di7 := di6.deepSplit()
+	fmt.Println("di7 :", di7)
+	di8 := di7.mergePureFormat()
+	fmt.Println("di8 :", di8)
+	di9 := di8.deepSplit()
+	fmt.Println("di9 :", di9)
+}
+
+func unittest() {
+	s0 := "ab???cdeft%%s%dagdsg%%d%23523?f%dsaf?%s"
+	di_test := &DbInput{
+		format: s0}
+	fmt.Println(s0)
+	for i := 0; ; i++ {
+		if pos, c, ok := (*di_test).getFormatOrQuestionMarkPos(i); ok {
+			fmt.Println("pos: ", pos, " c:", string(c))
+		} else {
+			break
+		}
+	}
+
+	fmt.Println("----------------------")
+
+	for i := 0; ; i++ {
+		if pos, c, ok := (*di_test).getFormatPos(i); ok {
+			fmt.Println("pos: ", pos, " c:", string(c))
+		} else {
+			break
+		}
+	}
+
+	fp1 := &Param{pName: "fp1fp1"}
+	fmt.Println("fp1 :", fp1)
+
+	fp2 := &Param{pName: "fp2fp2"}
+	fmt.Println("fp2 :", fp2)
+
+	fp3 := &Param{pName: "fp3fp3"}
+	fmt.Println("fp3 :", fp3)
+
+	fp3.conflate(fp2)
+	fp3.conflate(fp1)
+	fmt.Println("new fp3 :", fp3)
+
+	s1 := "xxx"
+	s2 := "yyyy"
+	s3 := "zzzz"
+	di1 := &DbInput{
+		format: s1,
+		paras:  []*Param{&Param{pName: "xxxx"}},
+	}
+
+	fmt.Println("di1 :", di1)
+
+	di2 := &DbInput{
+		format: "",
+		paras:  []*Param{&Param{pName: "xxxx"}},
+	}
+
+	fmt.Println("di2 :", di2)
+	di3 := (*di1).concat(di2)
+	fmt.Println("di3 :", di3)
+
+	(*di1).next = di1
+	di4 := di1.appendTail(di2)
+	fmt.Println("di4 :", di4)
+
+	di5 := &DbInput{}
+	(*di5).next = di5
+
+	di5 = di5.appendTail(&DbInput{
+		format: s1,
+		paras:  []*Param{&Param{pName: "xxxx"}},
+	})
+	di5 = di5.appendTail(&DbInput{
+		format: s2,
+		paras:  []*Param{&Param{pName: "yyyy"}},
+	})
+	fmt.Println("di5 :", di5)
+
+	di6 := di5.likeStringJoin(&DbInput{
+		format: s3,
+		paras:  []*Param{&Param{pName: "zzz"}},
+	})
+	fmt.Println("di6 :", di6)
+
+	di7 := di6.merge()
+	fmt.Println("di7 :", di7)
+
+	unittest1()
+}
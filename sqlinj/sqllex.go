@@ -0,0 +1,423 @@
+package sqlinj
+
+import (
+	"go/token"
+	"strings"
+)
+
+// 被污染的参数在sql中的上下文
+const (
+	ctxExpression       = "expression"        // WHERE %s，可以拼接任意的sql
+	ctxKeyword          = "keyword"           // ORDER BY a %s，关键字或运算符的位置
+	ctxIdentifier       = "identifier"        // ORDER BY %s、FROM %s_shard
+	ctxValue            = "value"             // a = %s、IN (%s)、LIMIT %s
+	ctxStringLiteral    = "string-literal"    // a = '%s'
+	ctxQuotedIdentifier = "quoted-identifier" // "%s" 或 `%s`
+	ctxComment          = "comment"           // -- %s 或 /* %s */
+)
+
+// sqlPiece 重建的sql中的一段：常量文本，或者一个以%s方式填入的参数
+type sqlPiece struct {
+	text      string
+	positions []token.Pos // text 每个字节的位置，未知时为空
+	para      *Param
+	pos       token.Pos
+	marker    bool   // 绑定参数的占位符
+	verb      string // 以%d等动词填入的安全的值，text 为 0
+	value     *Param // 以%d等动词填入的参数，未知时为空
+}
+
+// sqlSlot 一个填入参数的位置以及它所在的上下文
+type sqlSlot struct {
+	para    *Param
+	pos     token.Pos
+	context string
+}
+
+// sqlPieces 将DbInput的follow链重建为sql文本，%% 还原为 %，占位符保留，非字符串的动词替换为数字
+func (di *DbInput) sqlPieces(d *Dialect) []sqlPiece {
+	var r []sqlPiece
+	ss := di.chainSlots(d)
+	n := 0
+	for l := di; l != nil; l = l.follow {
+		last := 0
+		slots := ss[n]
+		n++
+		k := 0
+		for _, s := range slots {
+			r = append(r, l.textPiece(last, s.start))
+			last = s.end
+			var para *Param
+			if s.verb != '?' {
+				// 参数与动词一一对应
+				if k < len(l.paras) {
+					para = l.paras[k]
+				}
+				k++
+			}
+			switch {
+			case para != nil && injectable(s.verb):
+				r = append(r, sqlPiece{para: para, pos: l.posAt(s.end - 1)})
+			case s.verb == '?':
+				p := l.textPiece(s.start, s.end)
+				p.marker = true
+				r = append(r, p)
+			default:
+				r = append(r, sqlPiece{text: "0", positions: []token.Pos{l.posAt(s.start)},
+					verb: l.format[s.start:s.end], value: para})
+			}
+		}
+		r = append(r, l.textPiece(last, len(l.format)))
+	}
+	return r
+}
+
+// textPiece format[start:end] 的常量文本，%% 还原为 %
+func (di *DbInput) textPiece(start, end int) sqlPiece {
+	b := make([]byte, 0, end-start)
+	var positions []token.Pos
+	for i := start; i < end; i++ {
+		b = append(b, di.format[i])
+		if di.hasPositions() {
+			positions = append(positions, di.positions[i])
+		}
+		if di.format[i] == '%' && i+1 < end && di.format[i+1] == '%' {
+			i++
+		}
+	}
+	return sqlPiece{text: string(b), positions: positions}
+}
+
+// sqlSlots 每个以%s方式填入的参数在sql中的上下文
+func (di *DbInput) sqlSlots(d *Dialect) []sqlSlot {
+	return scanSQL(di.sqlPieces(d), d).slots
+}
+
+// sqlTokens 重建的sql的记号，注释被忽略
+func (di *DbInput) sqlTokens(d *Dialect) []Token {
+	return scanSQL(di.sqlPieces(d), d).tokens
+}
+
+// sql词法分析的状态
+const (
+	lexNormal = iota
+	lexString
+	lexQuotedIdent
+	lexLineComment
+	lexBlockComment
+)
+
+// sql记号的类型
+const (
+	TokNone    = iota
+	TokKeyword // 关键字，text 为大写
+	TokWord    // 标识符或数字
+	TokString  // 字符串，text 为引号中的内容
+	TokQuoted  // 带引号的标识符，text 为引号中的内容
+	TokOperator
+	TokPunct  // ( ) , . ; :
+	TokParam  // 以%s方式直接填入的参数
+	TokMarker // 绑定参数的占位符
+)
+
+// Token sql中的一个记号，para 不为空时记号中有以%s方式填入的参数
+type Token struct {
+	kind  int
+	text  string
+	depth int // 所在的括号层数
+	pos   token.Pos
+	para  *Param
+	ctx   string // TokParam 所在的上下文
+}
+
+// Kind 记号的类型，Tok 开头的常量之一
+func (t Token) Kind() int {
+	return t.kind
+}
+
+// Text 记号的文本，关键字为大写，字符串与带引号的标识符为引号中的内容
+func (t Token) Text() string {
+	return t.text
+}
+
+// Depth 记号所在的括号层数
+func (t Token) Depth() int {
+	return t.depth
+}
+
+// Pos 记号在源码中的位置，未知时为 token.NoPos
+func (t Token) Pos() token.Pos {
+	return t.pos
+}
+
+// Param 记号中以%s方式填入的参数，没有时为 nil
+func (t Token) Param() *Param {
+	return t.para
+}
+
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true,
+	"IN": true, "IS": true, "LIKE": true, "ILIKE": true, "BETWEEN": true, "ORDER": true,
+	"GROUP": true, "BY": true, "HAVING": true, "LIMIT": true, "OFFSET": true, "JOIN": true,
+	"LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true, "CROSS": true, "FULL": true,
+	"ON": true, "AS": true, "INSERT": true, "INTO": true, "VALUES": true, "UPDATE": true,
+	"SET": true, "DELETE": true, "ASC": true, "DESC": true, "DISTINCT": true, "UNION": true,
+	"ALL": true, "CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "EXISTS": true,
+	"TABLE": true, "RETURNING": true, "WITH": true, "USING": true, "ESCAPE": true,
+}
+
+// sqlLexer 逐个字符扫描sql，记录记号、所在的子句以及括号
+type sqlLexer struct {
+	state     int
+	quote     byte
+	dialect   *Dialect    // 决定 "、\、# 与 ? 的含义，为空时使用默认方言
+	cur       *Token      // 正在读取的单词、字符串或带引号的标识符
+	buf       []byte      // cur 已经读取的内容
+	positions []token.Pos // 正在扫描的片段每个字节的位置
+	clause    string
+	parens    []string
+	tokens    []Token
+	slots     []sqlSlot
+}
+
+// scanSQL 扫描重建的sql，返回记号以及每个参数所在的上下文
+func scanSQL(pieces []sqlPiece, d *Dialect) *sqlLexer {
+	lx := &sqlLexer{dialect: d}
+	for _, p := range pieces {
+		lx.positions = p.positions
+		switch {
+		case p.para != nil:
+			lx.slot(p)
+		case p.marker:
+			lx.endWord()
+			lx.emit(TokMarker, p.text, lx.posAt(0))
+		default:
+			for i := 0; i < len(p.text); i++ {
+				i = lx.char(p.text, i)
+			}
+		}
+	}
+	lx.endWord()
+	return lx
+}
+
+func (lx *sqlLexer) posAt(i int) token.Pos {
+	if i < len(lx.positions) {
+		return lx.positions[i]
+	}
+	return token.NoPos
+}
+
+// slot 参数的上下文由所在的词法状态与前一个记号决定
+func (lx *sqlLexer) slot(p sqlPiece) {
+	context := ctxExpression
+	switch lx.state {
+	case lexString:
+		context = ctxStringLiteral
+		lx.mark(p.para)
+	case lexQuotedIdent:
+		context = ctxQuotedIdentifier
+		lx.mark(p.para)
+	case lexLineComment, lexBlockComment:
+		context = ctxComment
+	default:
+		if lx.cur != nil {
+			// prefix_%s 与标识符连在一起
+			context = ctxIdentifier
+			lx.mark(p.para)
+		} else {
+			context = lx.bareContext()
+			lx.emit(TokParam, "", p.pos)
+			lx.tokens[len(lx.tokens)-1].para = p.para
+			lx.tokens[len(lx.tokens)-1].ctx = context
+		}
+	}
+	lx.slots = append(lx.slots, sqlSlot{para: p.para, pos: p.pos, context: context})
+}
+
+// mark 正在读取的记号中有填入的参数
+func (lx *sqlLexer) mark(para *Param) {
+	if lx.cur.para == nil {
+		lx.cur.para = para
+	}
+}
+
+// last 最后一个记号
+func (lx *sqlLexer) last() Token {
+	if len(lx.tokens) == 0 {
+		return Token{}
+	}
+	return lx.tokens[len(lx.tokens)-1]
+}
+
+// isOperand 可以作为操作数的记号
+func (t Token) isOperand() bool {
+	switch t.kind {
+	case TokWord, TokString, TokQuoted, TokParam, TokMarker:
+		return true
+	}
+	return t.kind == TokPunct && t.text == ")"
+}
+
+// bareContext 不在引号与注释中的参数，按前一个记号分类
+func (lx *sqlLexer) bareContext() string {
+	prev := lx.last()
+	switch {
+	case prev.kind == TokNone:
+		return ctxExpression
+	case prev.isOperand():
+		return ctxKeyword
+	case prev.kind == TokOperator:
+		return ctxValue
+	case prev.kind == TokKeyword:
+		switch prev.text {
+		case "FROM", "JOIN", "INTO", "UPDATE", "TABLE", "BY", "SELECT", "DISTINCT", "AS":
+			return ctxIdentifier
+		case "LIMIT", "OFFSET", "LIKE", "ILIKE", "BETWEEN", "IN", "THEN", "ELSE", "WHEN":
+			return ctxValue
+		}
+		return ctxExpression
+	}
+	// ( , . ;
+	if prev.text == "." {
+		return ctxIdentifier
+	}
+	if len(lx.parens) > 0 {
+		switch lx.parens[len(lx.parens)-1] {
+		case "list", "call":
+			return ctxValue
+		}
+	}
+	switch lx.clause {
+	case "SELECT", "BY", "FROM":
+		return ctxIdentifier
+	case "VALUES":
+		return ctxValue
+	}
+	return ctxExpression
+}
+
+// char 处理第i个字符，返回最后处理的字符位置
+func (lx *sqlLexer) char(s string, i int) int {
+	c := s[i]
+	next := byte(0)
+	if i+1 < len(s) {
+		next = s[i+1]
+	}
+	switch lx.state {
+	case lexString:
+		if c == '\\' && next != 0 && lx.dialect.escapes() {
+			lx.buf = append(lx.buf, next)
+			return i + 1
+		}
+		if c == lx.quote {
+			if next == lx.quote {
+				lx.buf = append(lx.buf, c)
+				return i + 1
+			}
+			lx.state = lexNormal
+			lx.endWord()
+			return i
+		}
+		lx.buf = append(lx.buf, c)
+		return i
+	case lexQuotedIdent:
+		if c == lx.quote {
+			if next == lx.quote {
+				lx.buf = append(lx.buf, c)
+				return i + 1
+			}
+			lx.state = lexNormal
+			lx.endWord()
+			return i
+		}
+		lx.buf = append(lx.buf, c)
+		return i
+	case lexLineComment:
+		if c == '\n' {
+			lx.state = lexNormal
+		}
+		return i
+	case lexBlockComment:
+		if c == '*' && next == '/' {
+			lx.state = lexNormal
+			return i + 1
+		}
+		return i
+	}
+	if isWordChar(c) {
+		if lx.cur == nil {
+			lx.cur = &Token{kind: TokWord, depth: len(lx.parens), pos: lx.posAt(i)}
+		}
+		lx.buf = append(lx.buf, c)
+		return i
+	}
+	lx.endWord()
+	switch {
+	case lx.dialect.stringQuote(c):
+		lx.state = lexString
+		lx.quote = c
+		lx.cur = &Token{kind: TokString, depth: len(lx.parens), pos: lx.posAt(i)}
+	case c == '"' || c == '`':
+		lx.state = lexQuotedIdent
+		lx.quote = c
+		lx.cur = &Token{kind: TokQuoted, depth: len(lx.parens), pos: lx.posAt(i)}
+	case c == '-' && next == '-', c == '#' && lx.dialect.hashComment():
+		lx.state = lexLineComment
+	case c == '/' && next == '*':
+		lx.state = lexBlockComment
+		return i + 1
+	case c == '(':
+		kind := "group"
+		if prev := lx.last(); prev.kind == TokKeyword && (prev.text == "IN" || prev.text == "VALUES") {
+			kind = "list"
+		} else if prev.kind == TokWord {
+			kind = "call"
+		}
+		lx.emit(TokPunct, "(", lx.posAt(i))
+		lx.parens = append(lx.parens, kind)
+	case c == ')':
+		if len(lx.parens) > 0 {
+			lx.parens = lx.parens[:len(lx.parens)-1]
+		}
+		lx.emit(TokPunct, ")", lx.posAt(i))
+	case c == ',' || c == '.' || c == ';' || c == ':':
+		lx.emit(TokPunct, string(c), lx.posAt(i))
+		if c == ';' {
+			lx.clause = ""
+		}
+	case c == '?' && lx.dialect.questionMark():
+		lx.emit(TokMarker, "?", lx.posAt(i))
+	case strings.IndexByte("=<>!+-*/%|&^~?#", c) >= 0:
+		lx.emit(TokOperator, string(c), lx.posAt(i))
+	}
+	return i
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// endWord 正在读取的记号结束，关键字记录为子句
+func (lx *sqlLexer) endWord() {
+	t := lx.cur
+	if t == nil || lx.state != lexNormal {
+		return
+	}
+	lx.cur = nil
+	t.text = string(lx.buf)
+	lx.buf = lx.buf[:0]
+	if w := strings.ToUpper(t.text); t.kind == TokWord && t.para == nil && sqlKeywords[w] {
+		t.kind = TokKeyword
+		t.text = w
+		switch w {
+		case "SELECT", "FROM", "WHERE", "BY", "SET", "VALUES", "HAVING", "LIMIT", "ON":
+			lx.clause = w
+		}
+	}
+	lx.tokens = append(lx.tokens, *t)
+}
+
+func (lx *sqlLexer) emit(kind int, text string, pos token.Pos) {
+	lx.tokens = append(lx.tokens, Token{kind: kind, text: text, depth: len(lx.parens), pos: pos})
+}
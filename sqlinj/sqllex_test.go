@@ -0,0 +1,61 @@
+package sqlinj
+
+import (
+	"strings"
+	"testing"
+)
+
+// slotContexts format 中每个 %s 填入参数后所在的上下文
+func slotContexts(format string, d *Dialect) []string {
+	di := &DbInput{format: format}
+	for range verbs(format) {
+		di.paras = append(di.paras, &Param{pName: "x", pType: "string"})
+	}
+	var r []string
+	for _, s := range di.sqlSlots(d) {
+		r = append(r, s.context)
+	}
+	return r
+}
+
+func TestSlotContext(t *testing.T) {
+	tests := []struct {
+		format  string
+		dialect string
+		want    []string
+	}{
+		{"SELECT a FROM t WHERE %s", "mysql", []string{ctxExpression}},
+		{"SELECT a FROM t WHERE b = %s", "mysql", []string{ctxValue}},
+		{"SELECT a FROM t WHERE b IN (%s)", "mysql", []string{ctxValue}},
+		{"SELECT a FROM t ORDER BY %s", "mysql", []string{ctxIdentifier}},
+		{"SELECT a FROM t ORDER BY a %s", "mysql", []string{ctxKeyword}},
+		{"SELECT a FROM t_%s", "mysql", []string{ctxIdentifier}},
+		{"SELECT a FROM t WHERE b = '%s'", "mysql", []string{ctxStringLiteral}},
+		{"SELECT a FROM t -- %s", "mysql", []string{ctxComment}},
+		{"SELECT a FROM t /* %s */", "mysql", []string{ctxComment}},
+		{"SELECT `%s` FROM t", "mysql", []string{ctxQuotedIdentifier}},
+		// mysql 中 " 是字符串，其他方言中是带引号的标识符
+		{`SELECT a FROM t WHERE b = "%s"`, "mysql", []string{ctxStringLiteral}},
+		{`SELECT "%s" FROM t`, "postgres", []string{ctxQuotedIdentifier}},
+		{`SELECT a FROM t WHERE b = "x"" AND c = %s"`, "mysql", []string{ctxStringLiteral}},
+		// 两个引号是字符串中的一个引号，不结束字符串
+		{"SELECT a FROM t WHERE b = 'x'' AND c = %s'", "mysql", []string{ctxStringLiteral}},
+		{"SELECT a FROM t WHERE b = 'x''' AND c = %s", "mysql", []string{ctxValue}},
+		{"SELECT `a``%s` FROM t", "mysql", []string{ctxQuotedIdentifier}},
+		// mysql 的字符串中 \' 不结束字符串，postgres 中结束
+		{`SELECT a FROM t WHERE b = 'x\' AND c = %s`, "mysql", []string{ctxStringLiteral}},
+		{`SELECT a FROM t WHERE b = 'x\' AND c = %s`, "postgres", []string{ctxValue}},
+		// 只有 mysql 中 # 开始行注释
+		{"SELECT a FROM t # %s", "mysql", []string{ctxComment}},
+		{"SELECT a FROM t WHERE b # %s", "postgres", []string{ctxValue}},
+		// postgres 中 ? 是运算符而不是占位符
+		{"SELECT a FROM t WHERE b ? %s", "postgres", []string{ctxValue}},
+		{"SELECT a FROM t WHERE b = ? %s", "mysql", []string{ctxKeyword}},
+	}
+	for _, tt := range tests {
+		got := slotContexts(tt.format, dialects[tt.dialect])
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s (%s): got %v, want %v", tt.format, tt.dialect, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+package sqlinj
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go/ast"
+	"go/build"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// FuncSummary 函数的污点摘要：参数如何流入返回值（以及在格式中的位置），哪些参数到达数据库调用，哪些参数被安全使用
+type FuncSummary struct {
+	Func      string           `json:"func"`
+	Params    []string         `json:"params"`
+	Variadic  bool             `json:"variadic,omitempty"`
+	Returns   []*SummaryReturn `json:"returns,omitempty"`
+	Sinks     []int            `json:"sinks,omitempty"`
+	Sanitized []int            `json:"sanitized,omitempty"`
+}
+
+// SummaryReturn 一个 return 语句在第 Index 个返回值上返回的值
+type SummaryReturn struct {
+	Index     int                `json:"index"`
+	Fragments []*SummaryFragment `json:"fragments"`
+}
+
+// SummaryFragment follow 链中一个 DbInput 的可序列化形式，Paras[i] 填入 Format 中第 i 个动词
+type SummaryFragment struct {
+	Format string        `json:"format"`
+	Paras  []SummaryPara `json:"paras,omitempty"`
+}
+
+// SummaryPara Param 为被调用函数参数的下标，a, b string 算两个参数，值不来自参数时为 -1；
+// Path 为参数之下的字段路径（如 .Name），Param 为 -1 时为完整的名字
+type SummaryPara struct {
+	Param int    `json:"param"`
+	Path  string `json:"path,omitempty"`
+}
+
+// returnAt 返回位置index的值，多个return语句时取参数最多的一个
+func (fs *FuncSummary) returnAt(index int) *SummaryReturn {
+	var r *SummaryReturn
+	best := -1
+	for _, ret := range fs.Returns {
+		if ret.Index != index {
+			continue
+		}
+		count := 0
+		for _, f := range ret.Fragments {
+			for _, p := range f.Paras {
+				if p.Param >= 0 {
+					count++
+				}
+			}
+		}
+		if count >= best {
+			r = ret
+			best = count
+		}
+	}
+	return r
+}
+
+// acceptsArgs 调用处的实参与参数一一对应，可变参数可以对应零个或多个实参；
+// f(g()) 这样实参个数不同的调用无法按下标绑定
+func (fs *FuncSummary) acceptsArgs(call *ast.CallExpr) bool {
+	n := len(call.Args)
+	if fs.Variadic && !call.Ellipsis.IsValid() {
+		return n >= len(fs.Params)-1
+	}
+	return n == len(fs.Params)
+}
+
+// argsOf 参数i对应的实参，可变参数对应之后所有的实参
+func (fs *FuncSummary) argsOf(call *ast.CallExpr, i int) []ast.Expr {
+	if i >= len(call.Args) {
+		return nil
+	}
+	if fs.Variadic && i == len(fs.Params)-1 {
+		return call.Args[i:]
+	}
+	return call.Args[i : i+1]
+}
+
+// shortName 不带包路径和接收者的函数名
+func (fs *FuncSummary) shortName() string {
+	return fs.Func[strings.LastIndex(fs.Func, ".")+1:]
+}
+
+// instantiate 用调用处的实参替换摘要中的参数，常量片段保持常量，参数片段保留实参的污点
+func (sr *SummaryReturn) instantiate(callee string, args []*DbInput) *DbInput {
+	var r *DbInput
+	for _, f := range sr.Fragments {
+		d := &DbInput{format: f.Format}
+		if len(f.Paras) > 0 {
+			if f.slotCount() < len(f.Paras) {
+				d = &DbInput{
+					format: "%s",
+					paras:  []*Param{&Param{pName: callee + "()"}},
+				}
+			} else {
+				paras := []*DbInput{}
+				for _, p := range f.Paras {
+					paras = append(paras, p.bind(callee, args))
+				}
+				d = sprintfInputs(d, paras)
+			}
+		}
+		if d.Empty() && d.follow == nil {
+			continue
+		}
+		if r == nil {
+			r = d
+		} else {
+			r = r.add(d)
+		}
+	}
+	if r == nil {
+		return &DbInput{}
+	}
+	return r
+}
+
+func (sf *SummaryFragment) slotCount() int {
+	di := &DbInput{format: sf.Format}
+	count := 0
+	for {
+		if _, _, ok := di.getFormatPos(count); !ok {
+			return count
+		}
+		count++
+	}
+}
+
+// bind 摘要参数对应的实参，args 与展开后的参数一一对应；不是来自参数的值以 被调用函数():名字 表示，不会与调用者的参数重名
+func (sp SummaryPara) bind(callee string, args []*DbInput) *DbInput {
+	if sp.Param < 0 || sp.Param >= len(args) {
+		return &DbInput{
+			format: "%s",
+			paras:  []*Param{&Param{pName: callee + "():" + sp.Path}},
+		}
+	}
+	arg := args[sp.Param]
+	if sp.Path != "" && arg.follow == nil && arg.next == nil &&
+		len(arg.paras) == 1 && arg.paras[0] != nil {
+		return &DbInput{
+			format: "%s",
+			paras:  []*Param{&Param{pName: arg.paras[0].pName + sp.Path}},
+		}
+	}
+	return arg
+}
+
+func (fs *FuncSummary) addSink(i int) {
+	fs.Sinks = addIndex(fs.Sinks, i)
+}
+
+func (fs *FuncSummary) addSanitized(i int) {
+	fs.Sanitized = addIndex(fs.Sanitized, i)
+}
+
+// addReturn 多条路径到达同一个返回语句时，相同形状的返回值只记录一次
+func (fs *FuncSummary) addReturn(r *SummaryReturn) {
+	for _, old := range fs.Returns {
+		if reflect.DeepEqual(old, r) {
+			return
+		}
+	}
+	fs.Returns = append(fs.Returns, r)
+}
+
+// finish 到达过数据库调用的参数不再视为已净化
+func (fs *FuncSummary) finish() {
+	sanitized := []int{}
+	for _, i := range fs.Sanitized {
+		if !hasIndex(fs.Sinks, i) {
+			sanitized = append(sanitized, i)
+		}
+	}
+	fs.Sanitized = sanitized
+}
+
+func addIndex(s []int, i int) []int {
+	if hasIndex(s, i) {
+		return s
+	}
+	s = append(s, i)
+	sort.Ints(s)
+	return s
+}
+
+func hasIndex(s []int, i int) bool {
+	for _, v := range s {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+// paramIndex 根据名字找到函数参数下标，p.X 形式返回参数下标和 ".X"
+func paramIndex(paras []Param, name string) (int, string) {
+	for i, p := range paras {
+		if p.pName == "_" {
+			continue
+		}
+		if name == p.pName {
+			return i, ""
+		}
+		if strings.Index(name, p.pName+".") == 0 {
+			return i, name[len(p.pName):]
+		}
+	}
+	return -1, name
+}
+
+// summaryFragments 将DbInput的follow链转换为可序列化的片段，集合类型不做记录
+func summaryFragments(di *DbInput, paras []Param) []*SummaryFragment {
+	if di.isCollection() {
+		return nil
+	}
+	var r []*SummaryFragment
+	for l := di; l != nil; l = l.follow {
+		f := &SummaryFragment{Format: l.format}
+		for _, p := range l.paras {
+			if p == nil {
+				continue
+			}
+			i, path := paramIndex(paras, p.pName)
+			f.Paras = append(f.Paras, SummaryPara{Param: i, Path: path})
+		}
+		r = append(r, f)
+	}
+	return r
+}
+
+// paraNames DbInput中出现的所有参数名，包括集合与未提交的参数
+func (di *DbInput) paraNames() []string {
+	var r []string
+	for _, p := range di.allParas() {
+		r = append(r, p.pName)
+	}
+	return r
+}
+
+// allParas 所有片段、待填入的参数以及集合元素中的参数
+func (di *DbInput) allParas() []*Param {
+	var r []*Param
+	seen := map[*DbInput]bool{}
+	var walk func(d *DbInput)
+	walk = func(d *DbInput) {
+		for l := d; l != nil && !seen[l]; l = l.follow {
+			seen[l] = true
+			for _, p := range l.paras {
+				if p != nil {
+					r = append(r, p)
+				}
+			}
+			if l.prepare != nil {
+				walk(l.prepare)
+			}
+			if l.next != nil {
+				walk(l.next)
+			}
+		}
+	}
+	walk(di)
+	return r
+}
+
+// packageSummaries 一个包内所有函数的摘要，Fingerprint 用于判断磁盘缓存是否仍然有效
+type packageSummaries struct {
+	Fingerprint string                  `json:"fingerprint"`
+	Funcs       map[string]*FuncSummary `json:"funcs"`
+}
+
+// summaryStore 在包之间共享的摘要表
+type summaryStore struct {
+	file         string
+	funcs        map[string]*FuncSummary
+	packages     map[string]*packageSummaries
+	cached       map[string]*packageSummaries
+	fingerprints map[string]string
+	decls        map[string]*ast.FuncDecl
+	pending      map[string]bool
+}
+
+func newSummaryStore(file string) *summaryStore {
+	return &summaryStore{
+		file:         file,
+		funcs:        make(map[string]*FuncSummary),
+		packages:     make(map[string]*packageSummaries),
+		cached:       make(map[string]*packageSummaries),
+		fingerprints: make(map[string]string),
+		decls:        make(map[string]*ast.FuncDecl),
+		pending:      make(map[string]bool),
+	}
+}
+
+// load 读取上次运行保存的摘要
+func (ss *summaryStore) load() error {
+	if ss.file == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(ss.file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &ss.cached)
+}
+
+// save 保存本次运行计算或复用的所有摘要
+func (ss *summaryStore) save() error {
+	if ss.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ss.packages, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ss.file, data, 0644)
+}
+
+// reuse 指纹一致时直接使用缓存的摘要
+func (ss *summaryStore) reuse(pkgPath string) bool {
+	c, ok := ss.cached[pkgPath]
+	if !ok || c.Fingerprint == "" || c.Fingerprint != ss.fingerprints[pkgPath] {
+		return false
+	}
+	ss.packages[pkgPath] = c
+	for k, s := range c.Funcs {
+		ss.funcs[k] = s
+	}
+	return true
+}
+
+// index 记录包内函数声明，以便调用者先于被调用者出现时按需计算摘要
+func (ss *summaryStore) index(pkg *packages.Package) {
+	ss.decls = make(map[string]*ast.FuncDecl)
+	for _, file := range pkg.Syntax {
+		for _, d := range file.Decls {
+			if fd, ok := d.(*ast.FuncDecl); ok && fd.Body != nil {
+				ss.decls[funcDeclKey(pkg, fd)] = fd
+			}
+		}
+	}
+	if _, ok := ss.packages[pkg.PkgPath]; !ok {
+		ss.packages[pkg.PkgPath] = &packageSummaries{
+			Fingerprint: ss.fingerprints[pkg.PkgPath],
+			Funcs:       make(map[string]*FuncSummary),
+		}
+	}
+}
+
+func (ss *summaryStore) put(pkgPath string, s *FuncSummary) {
+	s.finish()
+	ss.funcs[s.Func] = s
+	if p, ok := ss.packages[pkgPath]; ok {
+		p.Funcs[s.Func] = s
+	}
+}
+
+// funcDeclKey 与 types.Func.FullName 一致的函数名，没有类型信息时退化为 包路径.函数名
+func funcDeclKey(pkg *packages.Package, fd *ast.FuncDecl) string {
+	if pkg == nil {
+		return fd.Name.Name
+	}
+	if pkg.TypesInfo != nil {
+		if fn, ok := pkg.TypesInfo.Defs[fd.Name].(*types.Func); ok {
+			return fn.FullName()
+		}
+	}
+	return pkg.PkgPath + "." + fd.Name.Name
+}
+
+// calleeKey 被调用函数的名字，无法静态确定时返回空
+func calleeKey(pkg *packages.Package, call *ast.CallExpr) string {
+	if pkg == nil {
+		return ""
+	}
+	if pkg.TypesInfo != nil {
+		if fn := typeutil.StaticCallee(pkg.TypesInfo, call); fn != nil {
+			return fn.FullName()
+		}
+		return ""
+	}
+	if id, ok := call.Fun.(*ast.Ident); ok {
+		return pkg.PkgPath + "." + id.Name
+	}
+	return ""
+}
+
+// fingerprint 包文件内容与依赖指纹的哈希，依赖版本不变时指纹不变；标准库由 Go 的版本代表，不读取源码
+func (ss *summaryStore) fingerprint(pkg *packages.Package) string {
+	if f, ok := ss.fingerprints[pkg.PkgPath]; ok {
+		return f
+	}
+	ss.fingerprints[pkg.PkgPath] = ""
+	h := sha256.New()
+	h.Write([]byte(pkg.PkgPath + "\n"))
+	files := append([]string{}, pkg.GoFiles...)
+	sort.Strings(files)
+	for _, name := range files {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return ""
+		}
+		h.Write([]byte(name + "\n"))
+		h.Write(data)
+	}
+	imports := []string{}
+	for p := range pkg.Imports {
+		imports = append(imports, p)
+	}
+	sort.Strings(imports)
+	for _, p := range imports {
+		dep := pkg.Imports[p]
+		if isStdPackage(dep) {
+			h.Write([]byte(p + ":" + runtime.Version() + "\n"))
+			continue
+		}
+		h.Write([]byte(p + ":" + ss.fingerprint(dep) + "\n"))
+	}
+	f := hex.EncodeToString(h.Sum(nil))
+	ss.fingerprints[pkg.PkgPath] = f
+	return f
+}
+
+// isStdPackage 标准库不计算摘要
+func isStdPackage(pkg *packages.Package) bool {
+	if len(pkg.GoFiles) == 0 {
+		return true
+	}
+	return strings.HasPrefix(pkg.GoFiles[0], build.Default.GOROOT)
+}
+
+// sortPackages 按依赖关系排序，被依赖的包先分析，同一个包只出现一次
+func sortPackages(pkgs []*packages.Package) []*packages.Package {
+	inSet := map[string]*packages.Package{}
+	for _, p := range pkgs {
+		inSet[p.PkgPath] = p
+	}
+	visited := map[string]bool{}
+	result := []*packages.Package{}
+	var visit func(p *packages.Package)
+	visit = func(p *packages.Package) {
+		if visited[p.PkgPath] {
+			return
+		}
+		visited[p.PkgPath] = true
+		imports := []string{}
+		for path := range p.Imports {
+			imports = append(imports, path)
+		}
+		sort.Strings(imports)
+		for _, path := range imports {
+			if dep, ok := inSet[path]; ok {
+				visit(dep)
+			}
+		}
+		result = append(result, p)
+	}
+	for _, p := range pkgs {
+		if dep, ok := inSet[p.PkgPath]; ok {
+			visit(dep)
+		}
+	}
+	return result
+}
+
+// dependencies 目标包传递依赖中的非标准库包
+func dependencies(targets []*packages.Package) []*packages.Package {
+	isTarget := map[string]bool{}
+	for _, p := range targets {
+		isTarget[p.PkgPath] = true
+	}
+	seen := map[string]bool{}
+	result := []*packages.Package{}
+	var visit func(p *packages.Package)
+	visit = func(p *packages.Package) {
+		for _, dep := range p.Imports {
+			if seen[dep.PkgPath] || isTarget[dep.PkgPath] {
+				continue
+			}
+			seen[dep.PkgPath] = true
+			if isStdPackage(dep) {
+				continue
+			}
+			result = append(result, dep)
+			visit(dep)
+		}
+	}
+	for _, p := range targets {
+		visit(p)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/parser"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestFuncParameters(t *testing.T) {
+	tests := []struct {
+		sig  string
+		want string // 名字:类型，逗号分隔
+	}{
+		{"func(a, b string, c string)", "a:string,b:string,c:string"},
+		{"func(a string, n int, rest ...string)", "a:string,n:int,rest:string"},
+		{"func(string, int)", "_:string,_:int"},
+		{"func(_ string, b string)", "_:string,b:string"},
+		{"func()", ""},
+	}
+	for _, tt := range tests {
+		e, err := parser.ParseExpr(tt.sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range funcParameters(e.(*ast.FuncType)) {
+			got = append(got, p.pName+":"+p.pType)
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%s: got %v, want %s", tt.sig, got, tt.want)
+		}
+	}
+}
+
+func TestSummarySinks(t *testing.T) {
+	fl := newFixtureLoader()
+	caller, err := fl.load("fixture/xcall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	si := NewAnalyzer("")
+	si.checkPackages([]*packages.Package{caller, fl.pkgs["fixture/group"]}, nil)
+	tests := []struct {
+		fn    string
+		sinks []int
+	}{
+		// sink(db *sqlx.DB, a, b string) 中的 b 是第三个参数
+		{"fixture/group.sink", []int{2}},
+		{"fixture/group.Sink", []int{1}},
+		{"fixture/group.Unnamed", []int{3}},
+		{"fixture/xcall.Forward", []int{1}},
+	}
+	for _, tt := range tests {
+		s, ok := si.summaries.funcs[tt.fn]
+		if !ok {
+			t.Errorf("%s: no summary", tt.fn)
+			continue
+		}
+		if !reflect.DeepEqual(s.Sinks, tt.sinks) {
+			t.Errorf("%s: sinks %v, want %v", tt.fn, s.Sinks, tt.sinks)
+		}
+	}
+}
+
+func TestSummaryCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "summaries.json")
+	pkg := loadFixture(t, "group")
+	NewAnalyzer(file).checkPackages([]*packages.Package{pkg}, nil)
+
+	ss := newSummaryStore(file)
+	if err := ss.load(); err != nil {
+		t.Fatal(err)
+	}
+	ss.fingerprint(pkg)
+	if !ss.reuse(pkg.PkgPath) {
+		t.Fatal("summaries of an unchanged package are not reused")
+	}
+	if s := ss.funcs["fixture/group.sink"]; s == nil || !reflect.DeepEqual(s.Sinks, []int{2}) {
+		t.Errorf("cached summary of sink = %+v", s)
+	}
+	ss = newSummaryStore(file)
+	ss.load()
+	ss.fingerprints[pkg.PkgPath] = "changed"
+	if ss.reuse(pkg.PkgPath) {
+		t.Error("summaries are reused after the package changed")
+	}
+}
+func TestSummaryBind(t *testing.T) {
+	constArg := func(s string) *DbInput { return &DbInput{format: s} }
+	taintArg := func(name string) *DbInput {
+		return &DbInput{format: "%s", paras: []*Param{{pName: name, pType: "string"}}}
+	}
+	tests := []struct {
+		name  string
+		paras []SummaryPara
+		args  []*DbInput
+		want  string // 绑定后的参数名，逗号分隔
+	}{
+		// func wrap(a, b string, c string) 中的 c 是第三个参数
+		{"grouped", []SummaryPara{{Param: 2}}, []*DbInput{constArg("k"), constArg("k"), taintArg("u")}, "u"},
+		{"constant", []SummaryPara{{Param: 0}}, []*DbInput{constArg("k"), taintArg("u")}, ""},
+		{"field", []SummaryPara{{Param: 1, Path: ".Name"}}, []*DbInput{constArg("k"), taintArg("req")}, "req.Name"},
+		{"local", []SummaryPara{{Param: -1, Path: "x"}}, []*DbInput{taintArg("u")}, "wrap():x"},
+		{"two", []SummaryPara{{Param: 1}, {Param: 0}}, []*DbInput{taintArg("a"), taintArg("b")}, "b,a"},
+	}
+	for _, tt := range tests {
+		format := "x = %s" + strings.Repeat(" AND y = %s", len(tt.paras)-1)
+		sr := &SummaryReturn{Fragments: []*SummaryFragment{{Format: format, Paras: tt.paras}}}
+		di := sr.instantiate("wrap", tt.args)
+		if got := strings.Join(di.paraNames(), ","); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptsArgs(t *testing.T) {
+	tests := []struct {
+		params   int
+		variadic bool
+		call     string
+		want     bool
+	}{
+		{3, false, "f(a, b, c)", true},
+		{3, false, "f(a, b)", false},
+		{2, false, "f(pair())", false},
+		{2, true, "f(a)", true},
+		{2, true, "f(a, b, c, d)", true},
+		{2, true, "f()", false},
+		{2, true, "f(a, bs...)", true},
+		{2, true, "f(a, b, bs...)", false},
+	}
+	for _, tt := range tests {
+		e, err := parser.ParseExpr(tt.call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs := &FuncSummary{Params: make([]string, tt.params), Variadic: tt.variadic}
+		if got := fs.acceptsArgs(e.(*ast.CallExpr)); got != tt.want {
+			t.Errorf("%s with %d params: got %v, want %v", tt.call, tt.params, got, tt.want)
+		}
+	}
+}
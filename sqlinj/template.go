@@ -0,0 +1,297 @@
+package sqlinj
+
+import (
+	"go/ast"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template/parse"
+)
+
+// recordLocalExpr 记录 x := expr 形式的局部变量，用于找到模板与模板数据的定义
+func (si *Analyzer) recordLocalExpr(node *ast.AssignStmt) {
+	if len(node.Rhs) != 1 {
+		return
+	}
+	if id, ok := node.Lhs[0].(*ast.Ident); ok {
+		si.localExprs[id.Name] = node.Rhs[0]
+	}
+}
+
+// templateText 模板的文本，支持 template.Must(template.New(..).Parse(text)) 及保存它的局部变量与包级变量，
+// text 可以是常量、值为常量的变量或者 go:embed 的文件
+func (si *Analyzer) templateText(e ast.Expr) (string, bool) {
+	switch t := e.(type) {
+	case *ast.ParenExpr:
+		return si.templateText(t.X)
+	case *ast.Ident:
+		if init, ok := si.localExprs[t.Name]; ok {
+			return si.templateText(init)
+		}
+		if vs, _, i := si.varSpec(t); vs != nil && i < len(vs.Values) {
+			return si.templateText(vs.Values[i])
+		}
+	case *ast.CallExpr:
+		switch fn := t.Fun.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := fn.X.(*ast.Ident); ok && x.Name == "template" && fn.Sel.Name == "Must" && len(t.Args) == 1 {
+				return si.templateText(t.Args[0])
+			}
+			if fn.Sel.Name == "Parse" && len(t.Args) == 1 {
+				return si.stringValue(t.Args[0])
+			}
+		}
+	}
+	return "", false
+}
+
+// stringValue 常量字符串、go:embed 的文件内容或者已知为常量的变量
+func (si *Analyzer) stringValue(e ast.Expr) (string, bool) {
+	if s, ok := si.constArg(e); ok {
+		return s, true
+	}
+	if id, ok := e.(*ast.Ident); ok {
+		if s, ok := si.embedText(id); ok {
+			return s, true
+		}
+	}
+	return si.getDbInputFromRhs(e).constText()
+}
+
+// embedText //go:embed 修饰的包级字符串变量的文件内容
+func (si *Analyzer) embedText(id *ast.Ident) (string, bool) {
+	vs, gd, _ := si.varSpec(id)
+	if vs == nil {
+		return "", false
+	}
+	doc := vs.Doc
+	if doc == nil && len(gd.Specs) == 1 {
+		doc = gd.Doc
+	}
+	if doc == nil {
+		return "", false
+	}
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, "//go:embed ") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(c.Text, "//go:embed "))
+		if len(fields) != 1 {
+			return "", false
+		}
+		dir := filepath.Dir(si.pkg.Fset.Position(vs.Pos()).Filename)
+		data, err := ioutil.ReadFile(filepath.Join(dir, strings.Trim(fields[0], "`\"")))
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+	return "", false
+}
+
+// templateExecute tpl.Execute(&buf, data) 与 tpl.ExecuteTemplate(&buf, name, data) 把模板的结果写入builder
+func (si *Analyzer) templateExecute(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	name := ""
+	var data ast.Expr
+	switch {
+	case sel.Sel.Name == "Execute" && len(call.Args) == 2:
+		data = call.Args[1]
+	case sel.Sel.Name == "ExecuteTemplate" && len(call.Args) == 3:
+		name, _ = si.constArg(call.Args[1])
+		data = call.Args[2]
+	default:
+		return false
+	}
+	w := si.builderName(call.Args[0])
+	if w == "" {
+		return false
+	}
+	text, ok := si.templateText(sel.X)
+	if !ok {
+		return false
+	}
+	if di := si.renderTemplate(text, name, data); di != nil {
+		si.appendBuilder(w, di)
+	}
+	return true
+}
+
+// renderTemplate 模板文本作为格式：文本是常量，每个动作是一个 %s，动作引用的字段绑定到数据参数的对应字段
+func (si *Analyzer) renderTemplate(text string, name string, data ast.Expr) *DbInput {
+	tree := parse.New("sql")
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		return nil
+	}
+	if t, ok := trees[name]; ok && name != "" {
+		tree = t
+	}
+	if tree.Root == nil {
+		return nil
+	}
+	format := ""
+	paras := []*DbInput{}
+	si.walkTemplate(tree.Root, data, nil, &format, &paras)
+	return sprintfInputs(&DbInput{format: format}, paras)
+}
+
+// walkTemplate if 与 range 的各个分支都展开一次，dot 是当前 {{.}} 相对于数据参数的字段路径
+func (si *Analyzer) walkTemplate(node parse.Node, data ast.Expr, dot []string, format *string, paras *[]*DbInput) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			si.walkTemplate(c, data, dot, format, paras)
+		}
+	case *parse.TextNode:
+		*format += strings.Replace(string(n.Text), "%", "%%", -1)
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 {
+			return
+		}
+		*format += "%s"
+		*paras = append(*paras, si.templatePipe(n.Pipe, data, dot))
+	case *parse.IfNode:
+		si.walkTemplate(n.List, data, dot, format, paras)
+		si.walkTemplate(n.ElseList, data, dot, format, paras)
+	case *parse.RangeNode:
+		si.walkTemplate(n.List, data, pipeField(n.Pipe, dot), format, paras)
+		si.walkTemplate(n.ElseList, data, dot, format, paras)
+	case *parse.WithNode:
+		si.walkTemplate(n.List, data, pipeField(n.Pipe, dot), format, paras)
+		si.walkTemplate(n.ElseList, data, dot, format, paras)
+	}
+}
+
+// pipeField {{range .Items}} 与 {{with .Item}} 中 dot 对应的字段路径
+func pipeField(pipe *parse.PipeNode, dot []string) []string {
+	if len(pipe.Cmds) == 1 && len(pipe.Cmds[0].Args) == 1 {
+		if f, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode); ok {
+			return append(append([]string{}, dot...), f.Ident...)
+		}
+	}
+	return dot
+}
+
+// templatePipe 动作的值：引用的字段绑定到数据参数，len 的结果是安全的，字符串常量保持常量
+func (si *Analyzer) templatePipe(pipe *parse.PipeNode, data ast.Expr, dot []string) *DbInput {
+	var r *DbInput
+	for _, cmd := range pipe.Cmds {
+		if len(cmd.Args) > 0 {
+			if id, ok := cmd.Args[0].(*parse.IdentifierNode); ok && id.Ident == "len" {
+				return safeParaInput("len")
+			}
+		}
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				r = concatInput(r, si.templateField(data, append(append([]string{}, dot...), a.Ident...)))
+			case *parse.DotNode:
+				r = concatInput(r, si.templateField(data, dot))
+			case *parse.StringNode:
+				r = concatInput(r, &DbInput{format: a.Text})
+			}
+		}
+	}
+	if r == nil {
+		return safeParaInput("template")
+	}
+	return r
+}
+
+// templateField 数据参数中字段的值，数据是字面量时取对应的元素，否则按 data.Field 名字查找
+func (si *Analyzer) templateField(data ast.Expr, path []string) *DbInput {
+	if len(path) == 0 {
+		return si.getDbInputFromRhs(data)
+	}
+	if lit := si.compositeLit(data); lit != nil {
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key := ""
+			if id, ok := kv.Key.(*ast.Ident); ok {
+				key = id.Name
+			} else if s, ok := si.constArg(kv.Key); ok {
+				key = s
+			}
+			if key == path[0] {
+				return si.templateField(kv.Value, path[1:])
+			}
+		}
+		return safeParaInput(path[0])
+	}
+	name := exprName(data)
+	if name == "" {
+		return si.getDbInputFromRhs(data)
+	}
+	full := name + "." + strings.Join(path, ".")
+	if v, ok := si.allPossibleInput[full]; ok {
+		return v
+	}
+	if si.templateFieldSafe(data, path) {
+		return safeParaInput(full)
+	}
+	return si.getDbInputFromToken(full)
+}
+
+// compositeLit 表达式或者局部变量对应的 T{...}、&T{...}、map[string]T{...} 字面量
+func (si *Analyzer) compositeLit(e ast.Expr) *ast.CompositeLit {
+	switch t := e.(type) {
+	case *ast.CompositeLit:
+		return t
+	case *ast.UnaryExpr:
+		return si.compositeLit(t.X)
+	case *ast.Ident:
+		if init, ok := si.localExprs[t.Name]; ok {
+			return si.compositeLit(init)
+		}
+	}
+	return nil
+}
+
+// templateFieldSafe 字段类型安全或者带有 sqlinject:"trusted" 标签
+func (si *Analyzer) templateFieldSafe(data ast.Expr, path []string) bool {
+	if si.pkg == nil || si.pkg.TypesInfo == nil {
+		return false
+	}
+	t := si.pkg.TypesInfo.TypeOf(data)
+	for _, p := range path {
+		if t == nil {
+			return false
+		}
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Struct:
+			var next types.Type
+			for i := 0; i < u.NumFields(); i++ {
+				if u.Field(i).Name() == p {
+					if reflect.StructTag(u.Tag(i)).Get("sqlinject") == "trusted" {
+						return true
+					}
+					next = u.Field(i).Type()
+				}
+			}
+			t = next
+		case *types.Map:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		default:
+			return false
+		}
+	}
+	return t != nil && isSafeType(t)
+}
@@ -0,0 +1,36 @@
+package arity
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+func triple() (string, string, string) {
+	return "k", "k", "k"
+}
+
+func wrap(a, b string, c string) string {
+	return fmt.Sprintf("SELECT x FROM t WHERE x = %s", a)
+}
+
+// MultiValue wrap(triple()) 的实参个数与参数不同，不能按下标绑定
+func MultiValue(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, wrap(triple()))
+}
+
+func where(col string, args ...string) string {
+	return fmt.Sprintf("SELECT x FROM t WHERE %s = %s", col, args[0])
+}
+
+// Variadic 可变参数对应之后所有的实参
+func Variadic(db *sqlx.DB, u string) error {
+	var x int
+	return db.Get(&x, where("a", u))
+}
+
+func Const(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, where("a"))
+}
@@ -0,0 +1,66 @@
+package branch
+
+import "fixture/sqlx"
+
+func TaintFirst(db *sqlx.DB, name string, admin bool) error {
+	var x int
+	q := "SELECT a FROM t WHERE "
+	if admin {
+		q = q + name
+	} else {
+		q = "SELECT a FROM t"
+	}
+	return db.Get(&x, q)
+}
+
+func TaintLast(db *sqlx.DB, name string, admin bool) error {
+	var x int
+	q := "SELECT a FROM t"
+	if admin {
+		q = "SELECT a FROM t"
+	} else {
+		q = q + " WHERE " + name
+	}
+	return db.Get(&x, q)
+}
+
+func Safe(db *sqlx.DB, name string, admin bool) error {
+	var x int
+	q := "SELECT a FROM t"
+	if admin {
+		q = q + " WHERE b = 1"
+	}
+	return db.Get(&x, q)
+}
+
+func Switched(db *sqlx.DB, name string, k int) error {
+	var x int
+	q := "SELECT a FROM t"
+	switch k {
+	case 1:
+		q = q + " ORDER BY b"
+	case 2:
+		q = q + " ORDER BY " + name
+	}
+	return db.Get(&x, q)
+}
+
+func Reset(db *sqlx.DB, name string, admin bool) error {
+	var x int
+	q := "SELECT a FROM t WHERE " + name
+	if admin {
+		q = "SELECT a FROM t"
+	} else {
+		q = "SELECT b FROM t"
+	}
+	return db.Get(&x, q)
+}
+
+func Closure(db *sqlx.DB, name string) error {
+	q := "SELECT a FROM t WHERE " + name
+	f := func() error {
+		var x int
+		return db.Get(&x, q)
+	}
+	return f()
+}
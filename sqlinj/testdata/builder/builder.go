@@ -0,0 +1,44 @@
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"fixture/sqlx"
+)
+
+func SB(db *sqlx.DB, col string, id int) error {
+	var x int
+	var sb strings.Builder
+	sb.WriteString("SELECT ")
+	sb.WriteString(col)
+	sb.WriteByte(' ')
+	fmt.Fprintf(&sb, "FROM t WHERE id = %d", id)
+	return db.Get(&x, sb.String())
+}
+
+func Buf(db *sqlx.DB, name string) error {
+	var x int
+	buf := bytes.NewBufferString("SELECT a FROM t WHERE n = ")
+	buf.WriteString("?")
+	return db.Get(&x, buf.String(), name)
+}
+
+func Reset(db *sqlx.DB, name string) error {
+	var x int
+	sb := &strings.Builder{}
+	sb.WriteString(name)
+	sb.Reset()
+	sb.WriteString("SELECT 1")
+	return db.Get(&x, sb.String())
+}
+
+func Where(db *sqlx.DB, name string) error {
+	var x int
+	var b bytes.Buffer
+	b.WriteString("SELECT a FROM t WHERE n = '")
+	b.WriteString(name)
+	b.WriteString("'")
+	return db.Get(&x, b.String())
+}
@@ -0,0 +1,37 @@
+package conv
+
+import (
+	"fixture/sqlx"
+)
+
+type SQL string
+
+func Bytes(db *sqlx.DB, b []byte) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE b = '"+string(b)+"'")
+}
+
+func RoundTrip(db *sqlx.DB, name string) error {
+	var x int
+	q := []byte("SELECT a FROM t WHERE b = '")
+	q = append(q, name...)
+	q = append(q, '\'')
+	return db.Get(&x, string(q))
+}
+
+func Named(db *sqlx.DB, name string) error {
+	var x int
+	q := SQL("SELECT a FROM t WHERE b = '" + name + "'")
+	return db.Get(&x, string(q))
+}
+
+func NamedSafe(db *sqlx.DB, name string) error {
+	var x int
+	q := SQL("SELECT a FROM t WHERE b = ?")
+	return db.Get(&x, string([]rune(string(q))), name)
+}
+
+func Param(db *sqlx.DB, q SQL) error {
+	var x int
+	return db.Get(&x, string(q))
+}
@@ -0,0 +1,70 @@
+package decl
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+const base = "SELECT a FROM t WHERE %s"
+
+func Const(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf(base, name))
+}
+
+func ConstSafe(db *sqlx.DB, name string) error {
+	var x int
+	const order = " ORDER BY b"
+	return db.Get(&x, "SELECT a FROM t"+order)
+}
+
+func VarDecl(db *sqlx.DB, name string) error {
+	var x int
+	var q = "SELECT a FROM t WHERE name = '" + name + "'"
+	return db.Get(&x, q)
+}
+
+func Multi(db *sqlx.DB, name string) error {
+	var x int
+	q, w := "SELECT a FROM t", " WHERE name = '"+name+"'"
+	return db.Get(&x, q+w)
+}
+
+func Swap(db *sqlx.DB, name string) error {
+	var x int
+	a, b := "SELECT a FROM t", name
+	a, b = b, a
+	return db.Get(&x, b)
+}
+
+func build(name string) (string, []interface{}) {
+	return "SELECT a FROM t WHERE name = '" + name + "'", nil
+}
+
+func buildSafe(name string) (string, []interface{}) {
+	return "SELECT a FROM t WHERE name = ?", []interface{}{name}
+}
+
+func Tuple(db *sqlx.DB, name string) error {
+	var x int
+	q, args := build(name)
+	return db.Get(&x, q, args...)
+}
+
+func TupleSafe(db *sqlx.DB, name string) error {
+	var x int
+	q, args := buildSafe(name)
+	return db.Get(&x, q, args...)
+}
+
+func wrap(name string) (string, []interface{}) {
+	return build(name)
+}
+
+func Wrapped(db *sqlx.DB, name string) error {
+	var x int
+	_, _ = "", 1
+	q, _ := wrap(name)
+	return db.Get(&x, q)
+}
@@ -0,0 +1,66 @@
+package dia
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+func Two(db *sqlx.DB, a string, b string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE a = ? AND b = ?", a, b)
+}
+
+func QuotedMark(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE note = 'why?' AND b = '%s'", name))
+}
+
+func CommentMark(db *sqlx.DB, name string, id string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t /* ok? */ WHERE c = ? AND b = '%s'", name), id)
+}
+
+func BindAfterQuote(db *sqlx.DB, id string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE note = 'why?' AND b = ?", id)
+}
+
+func Pg(tx *sqlx.Tx, id string, name string) error {
+	var x int
+	return tx.Get(&x, fmt.Sprintf("SELECT a::text FROM t WHERE b = $1 AND c = '%s'", name), id)
+}
+
+func PgSafe(tx *sqlx.Tx, id string, name string) error {
+	var x int
+	return tx.Get(&x, "SELECT a::text FROM t WHERE b = $1 AND c = $2 AND d ? 'k'", id, name)
+}
+
+func TooFew(db *sqlx.DB, a string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE a = ? AND b = ?", a)
+}
+
+func Spread(db *sqlx.DB, args []interface{}) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE a = ? AND b = ?", args...)
+}
+
+func PgReuse(tx *sqlx.Tx, a string) error {
+	var x int
+	return tx.Get(&x, "SELECT a FROM t WHERE a = $1 OR b = $1", a)
+}
+
+func PgGap(tx *sqlx.Tx, a string, b string) error {
+	var x int
+	return tx.Get(&x, "SELECT a FROM t WHERE a = $1 OR b = $3", a, b)
+}
+
+func Branches(db *sqlx.DB, a string, all bool) error {
+	var x int
+	q := "SELECT a FROM t WHERE a = ?"
+	if all {
+		q = q + " AND b = ?"
+	}
+	return db.Get(&x, q, a)
+}
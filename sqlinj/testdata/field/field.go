@@ -0,0 +1,43 @@
+package field
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+type Meta struct {
+	Table string
+}
+
+type Req struct {
+	Limit int
+	Name  string
+	Sort  string `sqlinject:"trusted"`
+	Meta  Meta   `sqlinject:"trusted"`
+	Where string
+}
+
+func Limit(db *sqlx.DB, req *Req) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM %s ORDER BY %s LIMIT %s", req.Meta.Table, req.Sort, req.Limit))
+}
+
+func Name(db *sqlx.DB, req Req) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE n = '"+req.Name+"'")
+}
+
+func Overwritten(db *sqlx.DB, req Req) error {
+	var x int
+	req.Name = "fixed"
+	req.Where = "1=1"
+	req.Where += " AND b = 2"
+	return db.Get(&x, "SELECT a FROM t WHERE n = '"+req.Name+"' AND "+req.Where)
+}
+
+// Both 同一个参数的安全字段与被污染的字段
+func Both(db *sqlx.DB, req Req) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE n = %s LIMIT %d", req.Name, req.Limit))
+}
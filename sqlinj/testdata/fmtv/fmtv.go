@@ -0,0 +1,48 @@
+package fmtv
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+func Percent(db *sqlx.DB, name string, n int) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE b LIKE '%%abc%%' AND c = %d AND d = ?", n), name)
+}
+
+func Index(db *sqlx.DB, name string, n int) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE c = %[2]d AND d = '%[1]s'", name, n))
+}
+
+func IndexSafe(db *sqlx.DB, name string, n int) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE c = %[2]d AND d = %[2]d", name, n))
+}
+
+func Width(db *sqlx.DB, name string, n int) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE c = %*d AND d = '%-10s'", 5, n, name))
+}
+
+func V(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE d = '%+v'", name))
+}
+
+func Q(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE d = %q", name))
+}
+
+func Hex(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE d = X'%x'", name))
+}
+
+func Args(db *sqlx.DB, name string) error {
+	var x int
+	args := []interface{}{1, name}
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE c = %d AND d = '%s'", args...))
+}
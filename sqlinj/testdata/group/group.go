@@ -0,0 +1,33 @@
+package group
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+func wrap(a, b string, c string) string {
+	return fmt.Sprintf("SELECT x FROM t WHERE x = %s", c)
+}
+
+func Grouped(db *sqlx.DB, u string) error {
+	var x int
+	return db.Get(&x, wrap("k", "k", u))
+}
+
+func sink(db *sqlx.DB, a, b string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT x FROM t WHERE x = %s", b))
+}
+
+func Sink(db *sqlx.DB, u string) error {
+	return sink(db, "k", u)
+}
+
+func unnamed(string, int) {}
+
+func Unnamed(db *sqlx.DB, a, b string, c string) error {
+	unnamed(a, 1)
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT x FROM t WHERE x = %s", c))
+}
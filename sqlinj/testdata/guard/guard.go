@@ -0,0 +1,135 @@
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"fixture/sqlx"
+)
+
+var allowedCols = map[string]bool{"name": true, "date": true}
+
+const identPattern = `^[a-z_]+$`
+
+var identRe = regexp.MustCompile(identPattern)
+var looseRe = regexp.MustCompile(`[a-z_]+`)
+
+func MapOk(db *sqlx.DB, col string) error {
+	var x int
+	if _, ok := allowedCols[col]; !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+// MapBody 校验只覆盖 if 的内部
+func MapBody(db *sqlx.DB, col string) error {
+	var x int
+	if allowedCols[col] {
+		db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func Switch(db *sqlx.DB, col string) error {
+	var x int
+	switch col {
+	case "name", "date":
+	default:
+		return errors.New("bad")
+	}
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t ORDER BY %s", col))
+}
+
+func PkgRe(db *sqlx.DB, col string) error {
+	var x int
+	if !identRe.MatchString(col) {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func ConstPattern(db *sqlx.DB, col string) error {
+	var x int
+	if ok, _ := regexp.MatchString(identPattern, col); !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func Grouped(db *sqlx.DB, dir string) error {
+	var x int
+	if ok, _ := regexp.MatchString(`^(?i:(asc|desc))$`, dir); !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY a "+dir)
+}
+
+func LooseRe(db *sqlx.DB, col string) error {
+	var x int
+	if !looseRe.MatchString(col) {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func DotStar(db *sqlx.DB, col string) error {
+	var x int
+	if ok, _ := regexp.MatchString(`^.*$`, col); !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func DotPlus(db *sqlx.DB, col string) error {
+	var x int
+	if ok, _ := regexp.MatchString(`^.+$`, col); !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+// Alternation 锚点只作用于两个分支的一端
+func Alternation(db *sqlx.DB, col string) error {
+	var x int
+	if ok, _ := regexp.MatchString(`^a|b$`, col); !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func Dash(db *sqlx.DB, col string) error {
+	var x int
+	if ok, _ := regexp.MatchString(`^[\w-]+$`, col); !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func DynamicPattern(db *sqlx.DB, p, col string) error {
+	var x int
+	if ok, _ := regexp.MatchString(p, col); !ok {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+// LocalRe 局部变量中的模式
+func LocalRe(db *sqlx.DB, col string) error {
+	var x int
+	re := regexp.MustCompile(`^[a-z_]+$`)
+	if !re.MatchString(col) {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
+
+func LocalAny(db *sqlx.DB, col string) error {
+	var x int
+	re := regexp.MustCompile(".*")
+	if !re.MatchString(col) {
+		return errors.New("bad")
+	}
+	return db.Get(&x, "SELECT a FROM t ORDER BY "+col)
+}
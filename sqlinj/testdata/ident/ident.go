@@ -0,0 +1,39 @@
+package ident
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+type SortOrder string
+
+const (
+	Asc  SortOrder = "ASC"
+	Desc SortOrder = "DESC"
+)
+
+var sortCols = map[string]string{"name": "user_name", "date": "created_at"}
+
+func Order(db *sqlx.DB, col string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t ORDER BY %s", col))
+}
+
+func EnumCol(db *sqlx.DB, col SortOrder) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t ORDER BY %s", col))
+}
+
+// ConstMap 常量map中取出的值只能是声明的常量之一
+func ConstMap(db *sqlx.DB, key string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t ORDER BY %s", sortCols[key]))
+}
+
+// DynMap map中的值来自参数
+func DynMap(db *sqlx.DB, key string, col string) error {
+	var x int
+	cols := map[string]string{"name": "user_name", "other": col}
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t ORDER BY %s", cols[key]))
+}
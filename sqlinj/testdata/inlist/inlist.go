@@ -0,0 +1,66 @@
+package inlist
+
+import (
+	"strings"
+
+	"fixture/sqlx"
+)
+
+func TrimRepeat(db *sqlx.DB, ids []string) error {
+	var x int
+	args := []interface{}{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	q := "SELECT a FROM t WHERE id IN (" + strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",") + ")"
+	return db.Get(&x, q, args...)
+}
+
+func SliceRepeat(db *sqlx.DB, ids []string) error {
+	var x int
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	q := "SELECT a FROM t WHERE id IN (" + strings.Repeat(",?", len(ids))[1:] + ")"
+	return db.Get(&x, q, args...)
+}
+
+func Reslice(db *sqlx.DB, ids []string) error {
+	var x int
+	p := strings.Repeat("?,", len(ids))
+	p = p[:len(p)-1]
+	return db.Get(&x, "SELECT a FROM t WHERE id IN ("+p+")", ids)
+}
+
+func MakeFill(db *sqlx.DB, ids []string) error {
+	var x int
+	marks := make([]string, len(ids))
+	for i := range marks {
+		marks[i] = "?"
+	}
+	return db.Get(&x, "SELECT a FROM t WHERE id IN ("+strings.Join(marks, ",")+")", ids)
+}
+
+func AppendFill(db *sqlx.DB, ids []string) error {
+	var x int
+	marks := make([]string, 0, len(ids))
+	for range ids {
+		marks = append(marks, "?")
+	}
+	return db.Get(&x, "SELECT a FROM t WHERE id IN ("+strings.Join(marks, ",")+")", ids)
+}
+
+func Unsafe(db *sqlx.DB, ids []string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE id IN ('"+strings.Join(ids, "','")+"')")
+}
+
+func UnsafeFill(db *sqlx.DB, ids []string) error {
+	var x int
+	marks := make([]string, len(ids))
+	for i, id := range ids {
+		marks[i] = "'" + id + "'"
+	}
+	return db.Get(&x, "SELECT a FROM t WHERE id IN ("+strings.Join(marks, ",")+")")
+}
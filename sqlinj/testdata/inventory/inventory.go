@@ -0,0 +1,12 @@
+package inventory
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+func Page(db *sqlx.DB, name string, lim int) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE b = %s LIMIT %d", name, lim))
+}
@@ -0,0 +1,54 @@
+package like
+
+import (
+	"strings"
+
+	"fixture/sqlx"
+)
+
+func escapeLike(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "%", `\%`), "_", `\_`)
+}
+
+func Bound(db *sqlx.DB, q string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE b LIKE ?", q)
+}
+
+func Wrapped(db *sqlx.DB, q string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE b LIKE ?", "%"+q+"%")
+}
+
+func Index(db *sqlx.DB, q string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE a = ? AND b LIKE ?", 1, q)
+}
+
+func Spread(db *sqlx.DB, q string) error {
+	var x int
+	args := []interface{}{q}
+	return db.Get(&x, "SELECT a FROM t WHERE b LIKE ?", args...)
+}
+
+// Escaped 通配符已转义
+func Escaped(db *sqlx.DB, q string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE b LIKE ?", "%"+escapeLike(q)+"%")
+}
+
+// Equal 不是 LIKE 的模式
+func Equal(db *sqlx.DB, q string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE b = ?", q)
+}
+
+// Custom 由 -like-escapers 指定的转义函数
+func Custom(db *sqlx.DB, q string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE b LIKE ?", wild(q)+"%")
+}
+
+func wild(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "%", `\%`), "_", `\_`)
+}
@@ -0,0 +1,40 @@
+package lit
+
+import (
+	"fmt"
+	"strings"
+
+	"fixture/sqlx"
+)
+
+func Escaped(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE b = \"\x25s\"", name))
+}
+
+func Raw(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf(`SELECT a FROM t
+WHERE b = '%s'`, name))
+}
+
+func Quoted(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE b = \"x\" AND c = ?", name)
+}
+
+func Runes(db *sqlx.DB, name string) error {
+	var x int
+	sb := &strings.Builder{}
+	sb.WriteString("SELECT a FROM t WHERE b = ")
+	sb.WriteByte('\'')
+	sb.WriteString(name)
+	sb.WriteByte('\'')
+	return db.Get(&x, sb.String())
+}
+
+func Number(db *sqlx.DB, name string) error {
+	var x int
+	n := 10
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t LIMIT %d", n))
+}
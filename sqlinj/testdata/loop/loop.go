@@ -0,0 +1,60 @@
+package loop
+
+import (
+	"fmt"
+	"strings"
+
+	"fixture/sqlx"
+)
+
+type Filter struct {
+	Col string
+	Val string
+}
+
+func Filters(db *sqlx.DB, filters []Filter) error {
+	var x int
+	where := "1=1"
+	for _, f := range filters {
+		where += " AND " + f.Col + " = '" + f.Val + "'"
+	}
+	return db.Get(&x, "SELECT a FROM t WHERE "+where)
+}
+
+func Ids(db *sqlx.DB, ids []int) error {
+	var x int
+	where := "1=1"
+	for _, id := range ids {
+		where += fmt.Sprintf(" OR id = %d", id)
+	}
+	return db.Get(&x, "SELECT a FROM t WHERE "+where)
+}
+
+func Conds(db *sqlx.DB, names []string) error {
+	var x int
+	conds := []string{}
+	for _, n := range names {
+		conds = append(conds, "name = '"+n+"'")
+	}
+	return db.Get(&x, "SELECT a FROM t WHERE "+strings.Join(conds, " OR "))
+}
+
+func Later(db *sqlx.DB, names []string) error {
+	var x int
+	q := "SELECT a FROM t"
+	sep := " WHERE "
+	for i := 0; i < len(names); i++ {
+		q += sep
+		sep = " OR name = '" + names[i] + "'"
+	}
+	return db.Get(&x, q)
+}
+
+func Placeholders(db *sqlx.DB, names []string) error {
+	var x int
+	where := "1=1"
+	for range names {
+		where += " OR name = ?"
+	}
+	return db.Get(&x, "SELECT a FROM t WHERE "+where)
+}
@@ -0,0 +1,17 @@
+package mix
+
+import (
+	"fmt"
+
+	"fixture/sqlx"
+)
+
+func Mix(db *sqlx.DB, col string, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE b = ? AND c = %s", col), name)
+}
+
+func Lim(db *sqlx.DB, lim int, name string) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE b = ? LIMIT %d", lim), name)
+}
@@ -0,0 +1,4 @@
+package pq
+
+func QuoteIdentifier(s string) string { return s }
+func QuoteLiteral(s string) string    { return s }
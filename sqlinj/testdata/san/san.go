@@ -0,0 +1,43 @@
+package san
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"fixture/pq"
+	"fixture/sqlx"
+)
+
+type ID int64
+
+func Typed(db *sqlx.DB, id int64, uid ID, since time.Time) error {
+	var x int
+	return db.Get(&x, fmt.Sprintf("SELECT a FROM t WHERE id = %s AND u = %s AND s > '%s'", id, uid, since))
+}
+
+func Format(db *sqlx.DB, id int64) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE id = "+strconv.FormatInt(id, 10))
+}
+
+func Quoted(db *sqlx.DB, name string) error {
+	var x int
+	col := pq.QuoteIdentifier(name)
+	return db.Get(&x, "SELECT "+col+" FROM t")
+}
+
+func escape(s string) string {
+	return s
+}
+
+// Custom escape 只有用 -sanitizers 配置之后才是净化函数
+func Custom(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE n = '"+escape(name)+"'")
+}
+
+func Bad(db *sqlx.DB, name string) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE n = '"+name+"'")
+}
@@ -0,0 +1,14 @@
+package sqlx
+
+type Rows struct{}
+
+type DB struct{}
+
+func (db *DB) Get(dest interface{}, query string, args ...interface{}) error    { return nil }
+func (db *DB) Select(dest interface{}, query string, args ...interface{}) error { return nil }
+func (db *DB) Queryx(query string, args ...interface{}) (*Rows, error)          { return nil, nil }
+
+type Tx struct{}
+
+func (tx *Tx) Exec(query string, args ...interface{}) error                  { return nil }
+func (tx *Tx) Get(dest interface{}, query string, args ...interface{}) error { return nil }
@@ -0,0 +1,49 @@
+package star
+
+import (
+	"fixture/sqlx"
+)
+
+func Plain(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT * FROM t")
+}
+
+func Alias(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a.id, b.* FROM t a JOIN u b ON a.id = b.id")
+}
+
+func Count(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT COUNT(*) FROM t")
+}
+
+func Exists(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE EXISTS (SELECT * FROM u WHERE u.id = t.id)")
+}
+
+func Derived(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM (SELECT * FROM t) x")
+}
+
+func Mul(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a * 2 FROM t")
+}
+
+func InSub(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t WHERE id IN (SELECT * FROM u)")
+}
+
+func Union(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t UNION SELECT * FROM u")
+}
+
+func Insert(tx *sqlx.Tx) error {
+	return tx.Exec("INSERT INTO t SELECT * FROM u")
+}
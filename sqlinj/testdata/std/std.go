@@ -0,0 +1,44 @@
+package std
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fixture/sqlx"
+)
+
+func Upper(db *sqlx.DB, col string, id int) error {
+	var x int
+	q := strings.ToUpper("select a from t where c = '" + col + "' and id = ")
+	q = strings.TrimSpace("  " + q + fmt.Sprint(id, id) + "  ")
+	return db.Get(&x, q)
+}
+
+func Replace(db *sqlx.DB, v string) error {
+	var x int
+	q := strings.ReplaceAll("SELECT a FROM t WHERE v = '{v}'", "{v}", v)
+	return db.Get(&x, q)
+}
+
+func ReplaceConst(db *sqlx.DB, v string) error {
+	var x int
+	q := strings.Replace("SELECT a FROM TABLE WHERE v = ?", "TABLE", "users", 1)
+	return db.Get(&x, q, v)
+}
+
+func Split(db *sqlx.DB, v string) error {
+	var x int
+	parts := strings.Split("a,b,c", ",")
+	cols := strings.Split(v, ",")
+	db.Get(&x, "SELECT "+parts[1]+" FROM t")
+	db.Get(&x, "SELECT "+cols[0]+" FROM t")
+	return db.Get(&x, "SELECT "+strings.Repeat("x", 3)+strconv.Quote(v))
+}
+
+func Sprintf(db *sqlx.DB, n int, name string) error {
+	var x int
+	q := fmt.Sprintf("SELECT a FROM t LIMIT %v", n)
+	q += fmt.Sprint(" OFFSET ", strconv.Itoa(n))
+	return db.Get(&x, q+" -- "+strings.TrimSpace(name))
+}
@@ -0,0 +1 @@
+SELECT a FROM {{.Table}} WHERE name = '{{.Name}}'
\ No newline at end of file
@@ -0,0 +1,45 @@
+package tpl
+
+import (
+	"bytes"
+	_ "embed"
+	"text/template"
+
+	"fixture/sqlx"
+)
+
+//go:embed report.sql
+var reportSQL string
+
+var reportTpl = template.Must(template.New("r").Parse(reportSQL))
+
+var listTpl = template.Must(template.New("l").Parse(`SELECT {{.Cols}} FROM t WHERE {{.Cond}} LIMIT {{.Limit}}`))
+
+type Q struct {
+	Cols  string `sqlinject:"trusted"`
+	Cond  string
+	Limit int
+}
+
+func Report(db *sqlx.DB, name string) error {
+	var x int
+	var buf bytes.Buffer
+	reportTpl.Execute(&buf, map[string]string{"Table": "users", "Name": name})
+	return db.Get(&x, buf.String())
+}
+
+func List(db *sqlx.DB, q Q) error {
+	var x int
+	var buf bytes.Buffer
+	listTpl.Execute(&buf, q)
+	return db.Get(&x, buf.String())
+}
+
+func Local(db *sqlx.DB, limit int) error {
+	var x int
+	var buf bytes.Buffer
+	t := template.Must(template.New("x").Parse("SELECT a FROM t LIMIT {{.L}}"))
+	data := struct{ L int }{L: limit}
+	t.Execute(&buf, data)
+	return db.Get(&x, buf.String())
+}
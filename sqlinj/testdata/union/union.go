@@ -0,0 +1,15 @@
+package union
+
+import (
+	"fixture/sqlx"
+)
+
+func Union(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t UNION SELECT a FROM u")
+}
+
+func Plain(db *sqlx.DB) error {
+	var x int
+	return db.Get(&x, "SELECT a FROM t")
+}
@@ -0,0 +1,51 @@
+package where
+
+import (
+	"strings"
+
+	"fixture/sqlx"
+)
+
+func All(tx *sqlx.Tx) error {
+	return tx.Exec("DELETE FROM t")
+}
+
+func Ok(tx *sqlx.Tx, id int) error {
+	return tx.Exec("UPDATE t SET a = 1 WHERE id = ?", id)
+}
+
+func Sub(tx *sqlx.Tx) error {
+	return tx.Exec("UPDATE t SET a = (SELECT b FROM u WHERE u.id = 1)")
+}
+
+func Optional(tx *sqlx.Tx, id int) error {
+	where := ""
+	if id > 0 {
+		where = " WHERE id = ?"
+	}
+	return tx.Exec("DELETE FROM t"+where, id)
+}
+
+func Conds(tx *sqlx.Tx, ids []int) error {
+	conds := []string{}
+	for range ids {
+		conds = append(conds, "id = ?")
+	}
+	return tx.Exec("UPDATE t SET a = 1 WHERE " + strings.Join(conds, " OR "))
+}
+
+func OneEqOne(tx *sqlx.Tx, name string) error {
+	q := "DELETE FROM t WHERE 1=1"
+	if name != "" {
+		q += " AND name = ?"
+	}
+	return tx.Exec(q, name)
+}
+
+func Guarded(tx *sqlx.Tx) error {
+	return tx.Exec("DELETE FROM t WHERE 1=1 AND a = 2")
+}
+
+func Dynamic(tx *sqlx.Tx, where string) error {
+	return tx.Exec("DELETE FROM t " + where)
+}
@@ -0,0 +1,11 @@
+package xcall
+
+import (
+	"fixture/group"
+	"fixture/sqlx"
+)
+
+// Forward 参数经过另一个包的函数到达数据库调用
+func Forward(db *sqlx.DB, name string) error {
+	return group.Sink(db, name)
+}
@@ -0,0 +1,101 @@
+package sqlinj
+
+// statements 按顶层的 ; 拆分记号
+func statements(tokens []Token) [][]Token {
+	var r [][]Token
+	start := 0
+	for i, t := range tokens {
+		if t.kind == TokPunct && t.text == ";" && t.depth == 0 {
+			if i > start {
+				r = append(r, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		r = append(r, tokens[start:])
+	}
+	return r
+}
+
+// missingWhere UPDATE 或 DELETE 语句没有 WHERE、WHERE 之后没有条件或者条件恒为真时返回语句的第一个记号以及原因；
+// WHERE 之前的顶层有可能是整个子句的参数时无法确定，填入值或标识符的参数不会带来 WHERE
+func missingWhere(stmt []Token) (Token, string, bool) {
+	head := stmt[0]
+	if head.kind != TokKeyword || (head.text != "UPDATE" && head.text != "DELETE") {
+		return head, "", false
+	}
+	where := -1
+	for i, t := range stmt {
+		if t.depth != 0 {
+			continue
+		}
+		if t.kind == TokParam && (t.ctx == ctxExpression || t.ctx == ctxKeyword) {
+			return head, "", false
+		}
+		if t.kind == TokKeyword && t.text == "WHERE" {
+			where = i
+			break
+		}
+	}
+	if where < 0 {
+		return head, head.text + " without WHERE", true
+	}
+	var cond []Token
+	for _, t := range stmt[where+1:] {
+		if t.depth == 0 && t.kind == TokKeyword &&
+			(t.text == "ORDER" || t.text == "LIMIT" || t.text == "RETURNING") {
+			break
+		}
+		if t.kind == TokParam || t.para != nil {
+			return head, "", false
+		}
+		cond = append(cond, t)
+	}
+	if len(cond) == 0 {
+		return head, head.text + " with an empty WHERE", true
+	}
+	if alwaysTrue(cond) {
+		return head, head.text + " with an always true WHERE", true
+	}
+	return head, "", false
+}
+
+// alwaysTrue WHERE 1=1、WHERE 1、WHERE TRUE，通常是可选的条件都为空时留下的
+func alwaysTrue(cond []Token) bool {
+	switch len(cond) {
+	case 1:
+		return cond[0].kind == TokWord && (cond[0].text == "1" || isTrue(cond[0].text))
+	case 3:
+		return cond[0].kind == TokWord && cond[1].kind == TokOperator && cond[1].text == "=" &&
+			cond[2].kind == cond[0].kind && cond[2].text == cond[0].text && isDigits(cond[0].text)
+	}
+	return false
+}
+
+func isTrue(s string) bool {
+	return s == "true" || s == "TRUE" || s == "True"
+}
+
+// missingWhereRule 任何一条路径上的 UPDATE 或 DELETE 没有有效的 WHERE 都会报告，可能误删或误改整张表
+type missingWhereRule struct{}
+
+func (missingWhereRule) ID() string {
+	return RuleMissingWhere
+}
+
+func (missingWhereRule) Check(q *Query) []*Finding {
+	for _, stmt := range statements(q.Tokens) {
+		head, reason, ok := missingWhere(stmt)
+		if !ok {
+			continue
+		}
+		return []*Finding{{
+			Message:     reason,
+			Pos:         q.Position(head.pos),
+			Severity:    SeverityHigh,
+			Remediation: "add a WHERE clause that is not built only from optional fragments",
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package sqlinj
+
+import "testing"
+
+func TestMissingWhere(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string // 为空时不报告
+	}{
+		{"DELETE FROM t", "DELETE without WHERE"},
+		{"DELETE FROM t WHERE id = ?", ""},
+		{"DELETE FROM t WHERE", "DELETE with an empty WHERE"},
+		{"DELETE FROM t WHERE 1=1", "DELETE with an always true WHERE"},
+		{"DELETE FROM t WHERE 1=1 AND a = 2", ""},
+		{"UPDATE t SET a = (SELECT b FROM u WHERE u.id = 1)", "UPDATE without WHERE"},
+		{"SELECT a FROM t", ""},
+		{"DELETE FROM t; SELECT a FROM u WHERE b = 1", "DELETE without WHERE"},
+		// 填入表名或值的参数不会带来 WHERE
+		{"DELETE FROM %s", "DELETE without WHERE"},
+		{"UPDATE t SET a = %s", "UPDATE without WHERE"},
+		{"UPDATE t_%s SET a = 1", "UPDATE without WHERE"},
+		// 参数可能是整个 WHERE 子句
+		{"DELETE FROM t %s", ""},
+		{"UPDATE t SET %s", ""},
+		{"DELETE FROM t WHERE %s", ""},
+	}
+	for _, tt := range tests {
+		di := &DbInput{format: tt.format}
+		for range verbs(tt.format) {
+			di.paras = append(di.paras, &Param{pName: "x", pType: "string"})
+		}
+		got := ""
+		for _, stmt := range statements(di.sqlTokens(dialects["mysql"])) {
+			if _, reason, ok := missingWhere(stmt); ok {
+				got = reason
+				break
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.format, got, tt.want)
+		}
+	}
+}